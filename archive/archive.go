@@ -0,0 +1,268 @@
+/*
+Package archive keeps every fetched version of a JSON Feed in a directory,
+so the feed can be reconstructed as it was at any time.
+
+Each version is stored as a snapshot listing its items by ID,
+by their dates,
+and by a hash of their other fields.
+Each distinct item version is stored once,
+however many snapshots it appears in,
+so archiving a feed that rarely changes takes little space.
+Dates are kept out of the hash,
+since decoding gives an item with no dates the current time,
+so an unchanged item would otherwise differ on every fetch.
+
+The directory holds two subdirectories:
+
+	items/HASH.json       one item version with no dates, named by its SHA-256 hash
+	snapshots/TIME.json   the feed-level fields and item list of one version
+
+Files are written once and never changed,
+so an archive can be copied or backed up at any time.
+*/
+package archive
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/kr/jsonfeed"
+)
+
+// ErrNoSnapshot is returned by At when the archive
+// has no snapshot from the requested time or earlier.
+var ErrNoSnapshot = errors.New("archive: no snapshot")
+
+// timeFormat names snapshot files so they sort by time.
+const timeFormat = "20060102T150405.000000000Z"
+
+// An Archive is a directory of feed snapshots.
+type Archive struct {
+	dir string
+}
+
+// Open opens the archive in dir,
+// creating the directory if necessary.
+func Open(dir string) (*Archive, error) {
+	for _, sub := range []string{"items", "snapshots"} {
+		if err := os.MkdirAll(filepath.Join(dir, sub), 0o777); err != nil {
+			return nil, err
+		}
+	}
+	return &Archive{dir: dir}, nil
+}
+
+// A manifest is the contents of a snapshot file.
+type manifest struct {
+	Time  time.Time       `json:"time"`
+	Feed  json.RawMessage `json:"feed"` // with no items
+	Items []itemRef       `json:"items"`
+}
+
+type itemRef struct {
+	ID            string    `json:"id"`
+	Hash          string    `json:"hash"`
+	DatePublished time.Time `json:"date_published"`
+	DateModified  time.Time `json:"date_modified"`
+}
+
+// Add stores f as the version of the feed fetched at time t.
+// It is an error to add two snapshots with the same time.
+func (a *Archive) Add(f *jsonfeed.Feed, t time.Time) error {
+	if _, err := json.Marshal(f); err != nil {
+		return err // f is not valid
+	}
+	snap := filepath.Join(a.dir, "snapshots", t.UTC().Format(timeFormat)+".json")
+	if _, err := os.Stat(snap); err == nil {
+		return errors.New("archive: snapshot already exists for " + t.UTC().Format(time.RFC3339Nano))
+	}
+	meta := *f
+	meta.Items = nil
+	m := manifest{Time: t.UTC(), Items: make([]itemRef, len(f.Items))}
+	m.Feed, _ = json.Marshal(&meta) // valid, since f is
+	for i := range f.Items {
+		it := f.Items[i]
+		ref := itemRef{ID: it.ID, DatePublished: it.DatePublished, DateModified: it.DateModified}
+		it.DatePublished, it.DateModified = time.Time{}, time.Time{}
+		b, _ := json.Marshal(&it)
+		sum := sha256.Sum256(b)
+		h := hex.EncodeToString(sum[:])
+		ref.Hash = h
+		m.Items[i] = ref
+		name := filepath.Join(a.dir, "items", h+".json")
+		if _, err := os.Stat(name); err == nil {
+			continue // already stored
+		}
+		if err := writeFile(name, b); err != nil {
+			return err
+		}
+	}
+	b, _ := json.Marshal(&m) // manifests always encode
+	return writeFile(snap, b)
+}
+
+// writeFile writes b to the named file,
+// by way of a temporary file,
+// so the named file never holds partial contents.
+func writeFile(name string, b []byte) error {
+	f, err := os.CreateTemp(filepath.Dir(name), ".tmp-")
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(b)
+	if err1 := f.Close(); err == nil {
+		err = err1
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), name)
+	}
+	if err != nil {
+		os.Remove(f.Name())
+	}
+	return err
+}
+
+// Snapshots returns the times of the snapshots in the archive,
+// oldest first.
+func (a *Archive) Snapshots() ([]time.Time, error) {
+	ents, err := os.ReadDir(filepath.Join(a.dir, "snapshots"))
+	if err != nil {
+		return nil, err
+	}
+	var times []time.Time
+	for _, e := range ents {
+		s, ok := strings.CutSuffix(e.Name(), ".json")
+		if !ok {
+			continue
+		}
+		t, err := time.Parse(timeFormat, s)
+		if err != nil {
+			continue // not a snapshot
+		}
+		times = append(times, t)
+	}
+	sort.Slice(times, func(i, j int) bool { return times[i].Before(times[j]) })
+	return times, nil
+}
+
+// readManifest reads the snapshot from time t.
+func (a *Archive) readManifest(t time.Time) (*manifest, error) {
+	b, err := os.ReadFile(filepath.Join(a.dir, "snapshots", t.UTC().Format(timeFormat)+".json"))
+	if err != nil {
+		return nil, err
+	}
+	m := new(manifest)
+	if err := json.Unmarshal(b, m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// At returns the feed as it was at time t,
+// that is, the most recent snapshot taken at or before t.
+// It returns ErrNoSnapshot if there is none.
+func (a *Archive) At(t time.Time) (*jsonfeed.Feed, error) {
+	times, err := a.Snapshots()
+	if err != nil {
+		return nil, err
+	}
+	i := sort.Search(len(times), func(i int) bool { return times[i].After(t) })
+	if i == 0 {
+		return nil, ErrNoSnapshot
+	}
+	m, err := a.readManifest(times[i-1])
+	if err != nil {
+		return nil, err
+	}
+	f := new(jsonfeed.Feed)
+	if err := json.Unmarshal(m.Feed, f); err != nil {
+		return nil, err
+	}
+	f.Items = make([]jsonfeed.Item, len(m.Items))
+	for i, ref := range m.Items {
+		b, err := os.ReadFile(filepath.Join(a.dir, "items", ref.Hash+".json"))
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(b, &f.Items[i]); err != nil {
+			return nil, err
+		}
+		f.Items[i].DatePublished = ref.DatePublished
+		f.Items[i].DateModified = ref.DateModified
+	}
+	return f, nil
+}
+
+// A Lifetime describes when an item was in the feed.
+type Lifetime struct {
+	ID string
+
+	// FirstSeen and LastSeen are the times of the first
+	// and last snapshots that contain the item.
+	FirstSeen, LastSeen time.Time
+
+	// Removed is the time of the first snapshot
+	// after LastSeen, which lacks the item,
+	// or the zero time if the item is in the latest snapshot.
+	Removed time.Time
+
+	// Versions is the number of different versions
+	// of the item that were archived.
+	// Changes to the item's dates alone
+	// do not make a new version.
+	Versions int
+}
+
+// Lifetimes returns the lifetimes of all items
+// that have appeared in the feed,
+// ordered by FirstSeen, then by ID.
+func (a *Archive) Lifetimes() ([]*Lifetime, error) {
+	times, err := a.Snapshots()
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[string]*Lifetime)
+	hashes := make(map[string]map[string]bool)
+	var all []*Lifetime
+	for _, t := range times {
+		m, err := a.readManifest(t)
+		if err != nil {
+			return nil, err
+		}
+		present := make(map[string]bool, len(m.Items))
+		for _, ref := range m.Items {
+			present[ref.ID] = true
+			lt, ok := byID[ref.ID]
+			if !ok {
+				lt = &Lifetime{ID: ref.ID, FirstSeen: t}
+				byID[ref.ID] = lt
+				hashes[ref.ID] = make(map[string]bool)
+				all = append(all, lt)
+			}
+			lt.LastSeen, lt.Removed = t, time.Time{}
+			if !hashes[ref.ID][ref.Hash] {
+				hashes[ref.ID][ref.Hash] = true
+				lt.Versions++
+			}
+		}
+		for id, lt := range byID {
+			if !present[id] && lt.Removed.IsZero() {
+				lt.Removed = t
+			}
+		}
+	}
+	sort.SliceStable(all, func(i, j int) bool {
+		if !all[i].FirstSeen.Equal(all[j].FirstSeen) {
+			return all[i].FirstSeen.Before(all[j].FirstSeen)
+		}
+		return all[i].ID < all[j].ID
+	})
+	return all, nil
+}
@@ -0,0 +1,227 @@
+package archive
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/kr/jsonfeed"
+)
+
+func feed(items ...jsonfeed.Item) *jsonfeed.Feed {
+	return &jsonfeed.Feed{
+		Version: "https://jsonfeed.org/version/1",
+		Title:   "title",
+		Items:   items,
+	}
+}
+
+func item(id, text string) jsonfeed.Item {
+	d := time.Date(2017, 5, 17, 0, 0, 0, 0, time.UTC)
+	return jsonfeed.Item{ID: id, ContentText: text, DatePublished: d, DateModified: d}
+}
+
+func TestArchive(t *testing.T) {
+	dir := t.TempDir()
+	a, err := Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t0 := time.Date(2017, 5, 17, 10, 0, 0, 0, time.UTC)
+	t1, t2, t3 := t0.Add(time.Hour), t0.Add(2*time.Hour), t0.Add(3*time.Hour)
+	versions := []*jsonfeed.Feed{
+		feed(item("a", "a")),
+		feed(item("b", "b"), item("a", "a")),
+		feed(item("b", "b2")),
+		feed(item("a", "a"), item("b", "b2")),
+	}
+	for i, tm := range []time.Time{t0, t1, t2, t3} {
+		if err := a.Add(versions[i], tm); err != nil {
+			t.Fatalf("Add(%v) = %v", tm, err)
+		}
+	}
+	if err := a.Add(versions[0], t0.In(time.FixedZone("", 3600))); err == nil {
+		t.Errorf("Add(duplicate time) = nil, want error")
+	}
+
+	ents, _ := os.ReadDir(filepath.Join(dir, "items"))
+	if len(ents) != 3 {
+		t.Errorf("stored %d items, want 3", len(ents))
+	}
+
+	times, err := a.Snapshots()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []time.Time{t0, t1, t2, t3}; !reflect.DeepEqual(times, want) {
+		t.Errorf("Snapshots() = %v, want %v", times, want)
+	}
+
+	for i, tm := range []time.Time{t0, t1.Add(time.Minute), t2, t3.Add(time.Hour)} {
+		f, err := a.At(tm)
+		if err != nil {
+			t.Errorf("At(%v) = %v", tm, err)
+			continue
+		}
+		if !reflect.DeepEqual(f, versions[i]) {
+			t.Errorf("At(%v) = %+v, want %+v", tm, f, versions[i])
+		}
+	}
+	if _, err := a.At(t0.Add(-time.Second)); err != ErrNoSnapshot {
+		t.Errorf("At(before first) = %v, want ErrNoSnapshot", err)
+	}
+
+	lts, err := a.Lifetimes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []*Lifetime{
+		{ID: "a", FirstSeen: t0, LastSeen: t3, Versions: 1},
+		{ID: "b", FirstSeen: t1, LastSeen: t3, Versions: 2},
+	}
+	if !reflect.DeepEqual(lts, want) {
+		t.Errorf("Lifetimes() = %+v, want %+v", lts, want)
+	}
+
+	// Remove item a again.
+	t4 := t0.Add(4 * time.Hour)
+	if err := a.Add(feed(item("b", "b2")), t4); err != nil {
+		t.Fatal(err)
+	}
+	lts, _ = a.Lifetimes()
+	if lts[0].Removed != t4 || !lts[1].Removed.IsZero() {
+		t.Errorf("Lifetimes() = %+v, want a removed at %v", lts, t4)
+	}
+}
+
+func TestArchiveDateless(t *testing.T) {
+	a, _ := Open(t.TempDir())
+	t0 := time.Date(2017, 5, 17, 10, 0, 0, 0, time.UTC)
+	in := []byte(`{"version": "https://jsonfeed.org/version/1", "title": "t", "items": [{"id": "a", "content_text": "a"}]}`)
+	var fs []*jsonfeed.Feed
+	for i := range 2 {
+		f := new(jsonfeed.Feed)
+		if err := json.Unmarshal(in, f); err != nil {
+			t.Fatal(err)
+		}
+		f.Items[0].DatePublished = t0.Add(time.Duration(i) * time.Hour) // as filled in when decoded
+		f.Items[0].DateModified = f.Items[0].DatePublished
+		if err := a.Add(f, t0.Add(time.Duration(i)*time.Hour)); err != nil {
+			t.Fatal(err)
+		}
+		fs = append(fs, f)
+	}
+	lts, _ := a.Lifetimes()
+	if len(lts) != 1 || lts[0].Versions != 1 {
+		t.Errorf("Lifetimes() = %+v, want 1 version", lts)
+	}
+	for i, f := range fs {
+		tm := t0.Add(time.Duration(i) * time.Hour)
+		if got, err := a.At(tm); err != nil || !reflect.DeepEqual(got, f) {
+			t.Errorf("At(%v) = %+v, %v, want %+v", tm, got, err, f)
+		}
+	}
+}
+
+func TestLifetimesOrder(t *testing.T) {
+	a, _ := Open(t.TempDir())
+	t0 := time.Date(2017, 5, 17, 10, 0, 0, 0, time.UTC)
+	a.Add(feed(item("c", "c"), item("b", "b")), t0)
+	a.Add(feed(item("a", "a")), t0.Add(time.Hour))
+	lts, _ := a.Lifetimes()
+	var got []string
+	for _, lt := range lts {
+		got = append(got, lt.ID)
+	}
+	if want := []string{"b", "c", "a"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Lifetimes() IDs = %q, want %q", got, want)
+	}
+	if !lts[0].Removed.Equal(t0.Add(time.Hour)) {
+		t.Errorf("Removed = %v, want %v", lts[0].Removed, t0.Add(time.Hour))
+	}
+}
+
+func TestArchiveErrors(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "file")
+	os.WriteFile(file, nil, 0o666)
+	if _, err := Open(filepath.Join(file, "x")); err == nil {
+		t.Errorf("Open(under file) = nil, want error")
+	}
+
+	a, _ := Open(filepath.Join(dir, "a"))
+	t0 := time.Date(2017, 5, 17, 10, 0, 0, 0, time.UTC)
+	if err := a.Add(&jsonfeed.Feed{}, t0); err == nil {
+		t.Errorf("Add(invalid feed) = nil, want error")
+	}
+	bad := feed(item("a", "a"))
+	bad.Items[0].ContentText = ""
+	if err := a.Add(bad, t0); err == nil {
+		t.Errorf("Add(invalid item) = nil, want error")
+	}
+	os.WriteFile(filepath.Join(dir, "a", "snapshots", "README"), nil, 0o666)
+	os.WriteFile(filepath.Join(dir, "a", "snapshots", "notes.json"), nil, 0o666)
+	if times, err := a.Snapshots(); err != nil || len(times) != 0 {
+		t.Errorf("Snapshots() = %v, %v, want none", times, err)
+	}
+
+	// Corrupt files.
+	if err := a.Add(feed(item("a", "a")), t0); err != nil {
+		t.Fatal(err)
+	}
+	snap := filepath.Join(dir, "a", "snapshots", t0.Format(timeFormat)+".json")
+	b, _ := os.ReadFile(snap)
+	ents, _ := os.ReadDir(filepath.Join(dir, "a", "items"))
+	itemFile := filepath.Join(dir, "a", "items", ents[0].Name())
+	ib, _ := os.ReadFile(itemFile)
+
+	os.WriteFile(itemFile, []byte("[]"), 0o666)
+	if _, err := a.At(t0); err == nil {
+		t.Errorf("At(invalid item) = nil, want error")
+	}
+	os.Remove(itemFile)
+	if _, err := a.At(t0); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("At(missing item) = %v, want ErrNotExist", err)
+	}
+	if err := a.Add(feed(item("a", "a")), t0.Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := os.ReadFile(itemFile); string(got) != string(ib) {
+		t.Errorf("Add did not restore missing item")
+	}
+
+	os.WriteFile(snap, []byte(`{"feed":{}}`), 0o666)
+	if _, err := a.At(t0); err == nil {
+		t.Errorf("At(invalid feed) = nil, want error")
+	}
+	os.WriteFile(snap, b[:len(b)-1], 0o666)
+	if _, err := a.At(t0); err == nil {
+		t.Errorf("At(truncated snapshot) = nil, want error")
+	}
+	if _, err := a.Lifetimes(); err == nil {
+		t.Errorf("Lifetimes(truncated snapshot) = nil, want error")
+	}
+	os.Remove(snap)
+	os.Mkdir(snap, 0o777)
+	if _, err := a.At(t0); err == nil {
+		t.Errorf("At(unreadable snapshot) = nil, want error")
+	}
+
+	gone := &Archive{dir: filepath.Join(dir, "gone")}
+	if _, err := gone.At(t0); err == nil {
+		t.Errorf("At(no dir) = nil, want error")
+	}
+	if _, err := gone.Lifetimes(); err == nil {
+		t.Errorf("Lifetimes(no dir) = nil, want error")
+	}
+	if err := gone.Add(feed(item("a", "a")), t0); err == nil {
+		t.Errorf("Add(no dir) = nil, want error")
+	}
+	if err := writeFile(filepath.Join(dir, "a"), nil); err == nil {
+		t.Errorf("writeFile(onto dir) = nil, want error")
+	}
+}
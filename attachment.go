@@ -0,0 +1,135 @@
+package jsonfeed
+
+import (
+	"errors"
+	"strings"
+)
+
+// An AttachmentGroup holds alternate representations
+// of the same thing, such as an episode of a podcast
+// in several audio formats.
+// Its elements point into an item's Attachments.
+type AttachmentGroup []*Attachment
+
+// AttachmentGroups returns the attachments of t
+// grouped into alternate representations.
+// As the spec says, attachments with the same title are alternates.
+// An attachment with no title is in a group by itself.
+// Groups are in the order of their first attachment in t.Attachments,
+// and each group is in the same order as t.Attachments.
+func (t *Item) AttachmentGroups() []AttachmentGroup {
+	var groups []AttachmentGroup
+	index := make(map[string]int) // title to index in groups
+	for i := range t.Attachments {
+		a := &t.Attachments[i]
+		if j, ok := index[a.Title]; ok && a.Title != "" {
+			groups[j] = append(groups[j], a)
+			continue
+		}
+		index[a.Title] = len(groups)
+		groups = append(groups, AttachmentGroup{a})
+	}
+	return groups
+}
+
+// A Preference says which of several acceptable alternates
+// AttachmentGroup.Choose should pick.
+type Preference int
+
+const (
+	// PreferAccepted picks the alternate whose MIME type
+	// comes earliest in the accept list.
+	PreferAccepted Preference = iota
+
+	// PreferSmallest picks the alternate with the smallest
+	// SizeInBytes, to save bandwidth.
+	PreferSmallest
+
+	// PreferLargest picks the alternate with the largest
+	// SizeInBytes, presumably the highest quality.
+	PreferLargest
+)
+
+// Choose returns the best alternate in g whose MIME type
+// matches one in accept, according to p,
+// or nil if none matches.
+// Entries in accept are in order of preference,
+// and may use wildcards such as "audio/*" or "*/*";
+// an empty accept list matches everything.
+// Parameters such as "; codecs=opus" are ignored when matching.
+//
+// Alternates with no SizeInBytes are considered
+// only if none of the acceptable ones has a size.
+// Ties are broken by the order of accept, then of g.
+func (g AttachmentGroup) Choose(accept []string, p Preference) *Attachment {
+	var best *Attachment
+	bestRank := 0
+	for _, a := range g {
+		rank := acceptRank(accept, a.MIMEType)
+		if rank < 0 {
+			continue
+		}
+		if best == nil || better(a, rank, best, bestRank, p) {
+			best, bestRank = a, rank
+		}
+	}
+	return best
+}
+
+// better reports whether a, with accept rank ar,
+// is preferred over b, with rank br.
+func better(a *Attachment, ar int, b *Attachment, br int, p Preference) bool {
+	if p != PreferAccepted {
+		if (a.SizeInBytes == 0) != (b.SizeInBytes == 0) {
+			return b.SizeInBytes == 0
+		}
+		if a.SizeInBytes != b.SizeInBytes {
+			return (a.SizeInBytes < b.SizeInBytes) == (p == PreferSmallest)
+		}
+	}
+	return ar < br
+}
+
+// acceptRank returns the index of the first entry in accept
+// that matches the MIME type typ, or -1 if none matches.
+// If accept is empty, it returns 0.
+func acceptRank(accept []string, typ string) int {
+	if len(accept) == 0 {
+		return 0
+	}
+	typ = mediaType(typ)
+	major, _, _ := strings.Cut(typ, "/")
+	for i, s := range accept {
+		switch s = mediaType(s); {
+		case s == typ, s == "*/*", s == major+"/*":
+			return i
+		}
+	}
+	return -1
+}
+
+// mediaType returns the MIME type s,
+// in lower case and without parameters.
+func mediaType(s string) string {
+	s, _, _ = strings.Cut(s, ";")
+	return strings.ToLower(strings.TrimSpace(s))
+}
+
+// sameTypeAlternates returns an error if two alternates
+// in the same group have the same MIME type.
+// The spec says alternates should differ in type,
+// but feeds often list the same type at several bit rates,
+// so this is a lint warning, not a validation error.
+func sameTypeAlternates(t *Item) error {
+	for _, g := range t.AttachmentGroups() {
+		seen := make(map[string]bool)
+		for _, a := range g {
+			typ := mediaType(a.MIMEType)
+			if seen[typ] {
+				return errors.New("alternate attachments " + a.Title + " with the same mime_type " + typ)
+			}
+			seen[typ] = true
+		}
+	}
+	return nil
+}
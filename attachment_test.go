@@ -0,0 +1,79 @@
+package jsonfeed
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestAttachmentGroups(t *testing.T) {
+	it := &Item{Attachments: []Attachment{
+		{URL: "0", Title: "ep1"},
+		{URL: "1"},
+		{URL: "2", Title: "ep2"},
+		{URL: "3", Title: "ep1"},
+		{URL: "4"},
+	}}
+	var got [][]string
+	for _, g := range it.AttachmentGroups() {
+		var urls []string
+		for _, a := range g {
+			urls = append(urls, a.URL)
+		}
+		got = append(got, urls)
+	}
+	want := [][]string{{"0", "3"}, {"1"}, {"2"}, {"4"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("AttachmentGroups() = %q, want %q", got, want)
+	}
+	if g := it.AttachmentGroups(); g[0][1] != &it.Attachments[3] {
+		t.Errorf("AttachmentGroups() does not point into Attachments")
+	}
+	if g := (&Item{}).AttachmentGroups(); g != nil {
+		t.Errorf("AttachmentGroups() with no attachments = %v, want nil", g)
+	}
+}
+
+func TestAttachmentGroupChoose(t *testing.T) {
+	g := AttachmentGroup{
+		{URL: "mp3", MIMEType: "audio/mpeg", SizeInBytes: 300},
+		{URL: "aac", MIMEType: "audio/aac", SizeInBytes: 200},
+		{URL: "opus", MIMEType: "audio/ogg; codecs=opus"},
+		{URL: "flac", MIMEType: "audio/flac", SizeInBytes: 900},
+		{URL: "mp4", MIMEType: "video/mp4", SizeInBytes: 100},
+	}
+	cases := []struct {
+		accept []string
+		p      Preference
+		want   string
+	}{
+		{nil, PreferAccepted, "mp3"},
+		{nil, PreferSmallest, "mp4"},
+		{nil, PreferLargest, "flac"},
+		{[]string{"audio/ogg", "audio/aac"}, PreferAccepted, "opus"},
+		{[]string{"audio/ogg", "audio/aac"}, PreferSmallest, "aac"},
+		{[]string{"audio/ogg"}, PreferSmallest, "opus"},
+		{[]string{"AUDIO/*"}, PreferAccepted, "mp3"},
+		{[]string{"audio/*"}, PreferSmallest, "aac"},
+		{[]string{"audio/*"}, PreferLargest, "flac"},
+		{[]string{"text/html", "*/*"}, PreferAccepted, "mp3"},
+		{[]string{"text/html"}, PreferAccepted, ""},
+	}
+	for _, test := range cases {
+		got := ""
+		if a := g.Choose(test.accept, test.p); a != nil {
+			got = a.URL
+		}
+		if got != test.want {
+			t.Errorf("Choose(%q, %v) = %q, want %q", test.accept, test.p, got, test.want)
+		}
+	}
+
+	// Equal sizes fall back to the order of accept.
+	g = AttachmentGroup{
+		{URL: "mp3", MIMEType: "audio/mpeg", SizeInBytes: 100},
+		{URL: "aac", MIMEType: "audio/aac", SizeInBytes: 100},
+	}
+	if a := g.Choose([]string{"audio/aac", "audio/mpeg"}, PreferSmallest); a.URL != "aac" {
+		t.Errorf("Choose() = %q, want aac", a.URL)
+	}
+}
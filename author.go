@@ -0,0 +1,42 @@
+package jsonfeed
+
+// EffectiveAuthor returns the author of t, which is an item in f:
+// t.Author if it is set, or else f.Author,
+// as the spec says.
+// It returns nil if neither is set.
+func (t *Item) EffectiveAuthor(f *Feed) *Author {
+	if t.Author != nil {
+		return t.Author
+	}
+	return f.Author
+}
+
+// InheritAuthors sets the Author of each item in f
+// that has none to a copy of the feed's author.
+// It is the inverse of CompactAuthors.
+func (f *Feed) InheritAuthors() {
+	if f.Author == nil {
+		return
+	}
+	for i := range f.Items {
+		if f.Items[i].Author == nil {
+			a := *f.Author
+			f.Items[i].Author = &a
+		}
+	}
+}
+
+// CompactAuthors clears the Author of each item in f
+// that is equal to the feed's author,
+// since the feed's author applies to such items anyway.
+// It is the inverse of InheritAuthors.
+func (f *Feed) CompactAuthors() {
+	if f.Author == nil {
+		return
+	}
+	for i := range f.Items {
+		if a := f.Items[i].Author; a != nil && *a == *f.Author {
+			f.Items[i].Author = nil
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package jsonfeed
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestEffectiveAuthor(t *testing.T) {
+	kr, guest := &Author{Name: "kr"}, &Author{Name: "guest"}
+	cases := []struct {
+		feed, item, want *Author
+	}{
+		{nil, nil, nil},
+		{kr, nil, kr},
+		{nil, guest, guest},
+		{kr, guest, guest},
+	}
+	for _, test := range cases {
+		f := &Feed{Author: test.feed, Items: []Item{{Author: test.item}}}
+		if got := f.Items[0].EffectiveAuthor(f); got != test.want {
+			t.Errorf("EffectiveAuthor(%v, %v) = %v, want %v", test.feed, test.item, got, test.want)
+		}
+	}
+}
+
+func TestInheritCompactAuthors(t *testing.T) {
+	compact := &Feed{
+		Author: &Author{Name: "kr"},
+		Items: []Item{
+			{ID: "a"},
+			{ID: "b", Author: &Author{Name: "guest"}},
+			{ID: "c", Author: &Author{Name: "kr", URL: "https://kr.example/"}},
+		},
+	}
+	full := &Feed{
+		Author: &Author{Name: "kr"},
+		Items: []Item{
+			{ID: "a", Author: &Author{Name: "kr"}},
+			{ID: "b", Author: &Author{Name: "guest"}},
+			{ID: "c", Author: &Author{Name: "kr", URL: "https://kr.example/"}},
+		},
+	}
+
+	f := compact.Filter(nil)
+	f.InheritAuthors()
+	if !reflect.DeepEqual(f, full) {
+		t.Errorf("InheritAuthors() = %+v, want %+v", f, full)
+	}
+	if f.Items[0].Author == f.Author {
+		t.Errorf("InheritAuthors() shares the feed's author")
+	}
+	f.CompactAuthors()
+	if !reflect.DeepEqual(f, compact) {
+		t.Errorf("CompactAuthors() = %+v, want %+v", f, compact)
+	}
+
+	f = &Feed{Items: []Item{{ID: "a"}, {ID: "b", Author: &Author{Name: "guest"}}}}
+	want := f.Filter(nil)
+	f.InheritAuthors()
+	f.CompactAuthors()
+	if !reflect.DeepEqual(f, want) {
+		t.Errorf("with no feed author, got %+v, want %+v", f, want)
+	}
+}
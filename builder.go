@@ -0,0 +1,309 @@
+package jsonfeed
+
+import (
+	"errors"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// A Builder constructs a Feed one step at a time.
+// Each method checks its arguments as it goes
+// and records any problems,
+// which are reported together by Build.
+// Methods that affect an item, such as WithTag,
+// apply to the item most recently added with AddItem.
+//
+// The zero value is an empty builder ready to use.
+type Builder struct {
+	f    Feed
+	errs BuildErrors
+	ids  map[string]bool
+	fill []func(*Attachment) error
+	id   func(*Item) (string, error)
+	hist IDStore
+}
+
+// A BuildError describes a problem found by a Builder.
+type BuildError struct {
+	Item  int    // index of the item, or -1 for the feed itself
+	Field string // JSON name of the field, if any
+	Err   error
+}
+
+func (e *BuildError) Error() string {
+	s := "jsonfeed: "
+	if e.Item >= 0 {
+		s += "item " + strconv.Itoa(e.Item) + ": "
+	}
+	if e.Field != "" {
+		s += e.Field + ": "
+	}
+	return s + e.Err.Error()
+}
+
+func (e *BuildError) Unwrap() error {
+	return e.Err
+}
+
+// BuildErrors is a list of problems found by a Builder.
+type BuildErrors []*BuildError
+
+func (e BuildErrors) Error() string {
+	s := make([]string, len(e))
+	for i, err := range e {
+		s[i] = err.Error()
+	}
+	return strings.Join(s, "\n")
+}
+
+func (b *Builder) errorf(item int, field, msg string) {
+	b.errs = append(b.errs, &BuildError{Item: item, Field: field, Err: errors.New(msg)})
+}
+
+// url records an error if u is not an absolute URL.
+func (b *Builder) url(item int, field, u string) {
+	p, err := url.Parse(u)
+	if err != nil {
+		b.errs = append(b.errs, &BuildError{Item: item, Field: field, Err: err})
+	} else if !p.IsAbs() {
+		b.errorf(item, field, "not an absolute URL: "+u)
+	}
+}
+
+// Title sets the feed's title.
+func (b *Builder) Title(s string) *Builder {
+	b.f.Title = s
+	return b
+}
+
+// HomePage sets the feed's home page URL.
+func (b *Builder) HomePage(u string) *Builder {
+	b.url(-1, "home_page_url", u)
+	b.f.HomePageURL = u
+	return b
+}
+
+// FeedURL sets the URL of the feed itself.
+func (b *Builder) FeedURL(u string) *Builder {
+	b.url(-1, "feed_url", u)
+	b.f.FeedURL = u
+	return b
+}
+
+// NextURL sets the URL of the next page of the feed.
+func (b *Builder) NextURL(u string) *Builder {
+	b.url(-1, "next_url", u)
+	b.f.NextURL = u
+	return b
+}
+
+// Description sets the feed's description.
+func (b *Builder) Description(s string) *Builder {
+	b.f.Description = s
+	return b
+}
+
+// UserComment sets the feed's user comment.
+func (b *Builder) UserComment(s string) *Builder {
+	b.f.UserComment = s
+	return b
+}
+
+// Icon sets the URL of the feed's icon.
+func (b *Builder) Icon(u string) *Builder {
+	b.url(-1, "icon", u)
+	b.f.Icon = u
+	return b
+}
+
+// Favicon sets the URL of the feed's favicon.
+func (b *Builder) Favicon(u string) *Builder {
+	b.url(-1, "favicon", u)
+	b.f.Favicon = u
+	return b
+}
+
+// Author sets the feed's author.
+func (b *Builder) Author(a Author) *Builder {
+	if validAuthor(&a) != nil {
+		b.errorf(-1, "author", "no name or url or avatar")
+	}
+	b.f.Author = &a
+	return b
+}
+
+// Expired marks the feed as finished.
+func (b *Builder) Expired() *Builder {
+	b.f.Expired = true
+	return b
+}
+
+// Hub adds a hub with the given type and URL.
+func (b *Builder) Hub(typ, u string) *Builder {
+	if typ == "" {
+		b.errorf(-1, "hubs", "no type")
+	}
+	b.url(-1, "hubs", u)
+	b.f.Hubs = append(b.f.Hubs, Hub{Type: typ, URL: u})
+	return b
+}
+
+// GenerateIDs arranges for fn to make the ID
+// of each item added after this call that has none,
+// in place of its URL.
+// See TagIDs, HashIDs, and URLIDs.
+// An error from fn is recorded for the item.
+func (b *Builder) GenerateIDs(fn func(t *Item) (string, error)) *Builder {
+	b.id = fn
+	return b
+}
+
+// AddItem adds t to the feed.
+// If t has no ID, one is made by the function
+// given to GenerateIDs, if any,
+// or else its URL is used as the ID.
+// It is an error for t to have neither an ID nor a URL,
+// to have the same ID as an item already added,
+// or to have neither ContentHTML nor ContentText.
+func (b *Builder) AddItem(t Item) *Builder {
+	i := len(b.f.Items)
+	if t.ID == "" && b.id != nil {
+		id, err := b.id(&t)
+		if err != nil {
+			b.errs = append(b.errs, &BuildError{Item: i, Field: "id", Err: err})
+		}
+		t.ID = id
+	}
+	if t.ID == "" {
+		t.ID = t.URL
+	}
+	switch {
+	case t.ID == "":
+		b.errorf(i, "id", "no id or url")
+	case b.ids[t.ID]:
+		b.errorf(i, "id", "duplicate id "+t.ID)
+	}
+	if b.ids == nil {
+		b.ids = make(map[string]bool)
+	}
+	b.ids[t.ID] = true
+	if t.URL != "" {
+		b.url(i, "url", t.URL)
+	}
+	if t.ContentHTML == "" && t.ContentText == "" {
+		b.errorf(i, "content_text", "no content_html or content_text")
+	}
+	if validAuthor(t.Author) != nil {
+		b.errorf(i, "author", "no name or url or avatar")
+	}
+	atts := t.Attachments
+	t.Attachments = nil
+	b.f.Items = append(b.f.Items, t)
+	for _, a := range atts {
+		b.WithAttachment(a)
+	}
+	return b
+}
+
+// item returns the item most recently added,
+// or nil after recording an error if there is none.
+func (b *Builder) item(field string) *Item {
+	if len(b.f.Items) == 0 {
+		b.errorf(-1, field, "no item added")
+		return nil
+	}
+	return &b.f.Items[len(b.f.Items)-1]
+}
+
+// FillAttachments arranges for fn to be called
+// on each attachment added after this call,
+// before the attachment is checked.
+// It can fill in fields such as SizeInBytes
+// from the attachment's URL.
+// An error from fn is recorded for the item.
+func (b *Builder) FillAttachments(fn func(a *Attachment) error) *Builder {
+	b.fill = append(b.fill, fn)
+	return b
+}
+
+// WithAttachment adds a to the current item.
+func (b *Builder) WithAttachment(a Attachment) *Builder {
+	t := b.item("attachments")
+	if t == nil {
+		return b
+	}
+	i := len(b.f.Items) - 1
+	for _, fn := range b.fill {
+		if err := fn(&a); err != nil {
+			b.errs = append(b.errs, &BuildError{Item: i, Field: "attachments", Err: err})
+		}
+	}
+	if a.URL == "" {
+		b.errorf(i, "attachments", "no url")
+	} else {
+		b.url(i, "attachments", a.URL)
+	}
+	if a.MIMEType == "" {
+		b.errorf(i, "attachments", "no mime_type")
+	}
+	t.Attachments = append(t.Attachments, a)
+	return b
+}
+
+// WithTag adds tags to the current item.
+func (b *Builder) WithTag(tags ...string) *Builder {
+	t := b.item("tags")
+	if t == nil {
+		return b
+	}
+	for _, tag := range tags {
+		if tag == "" {
+			b.errorf(len(b.f.Items)-1, "tags", "empty tag")
+			continue
+		}
+		t.Tags = append(t.Tags, tag)
+	}
+	return b
+}
+
+// CheckIDs arranges for Build to check the feed's item IDs
+// against those recorded in s, as by the function CheckIDs.
+// Build does not record the IDs;
+// call RecordIDs once the feed is published.
+func (b *Builder) CheckIDs(s IDStore) *Builder {
+	b.hist = s
+	return b
+}
+
+// Build returns the feed constructed so far.
+// It sets Version in the feed.
+// If any step recorded a problem,
+// or the next URL is the same as the feed URL,
+// Build returns a nil feed and the problems as BuildErrors.
+// Otherwise, it returns an error if the feed fails validation,
+// for instance because it has no title,
+// or if it fails the check set up by CheckIDs.
+func (b *Builder) Build() (*Feed, error) {
+	errs := append(BuildErrors(nil), b.errs...)
+	if b.f.NextURL != "" && b.f.NextURL == b.f.FeedURL {
+		errs = append(errs, &BuildError{Item: -1, Field: "next_url", Err: errors.New("same as feed_url")})
+	}
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	f := new(Feed)
+	*f = b.f
+	f.Version = Version
+	f.Items = append([]Item(nil), b.f.Items...)
+	f.Hubs = append([]Hub(nil), b.f.Hubs...)
+	if err := validFeed(f); err != nil {
+		return nil, err
+	}
+	if b.hist != nil {
+		if err := CheckIDs(b.hist, f); err != nil {
+			return nil, err
+		}
+	}
+	return f, nil
+}
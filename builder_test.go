@@ -0,0 +1,237 @@
+package jsonfeed
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestBuilderOk(t *testing.T) {
+	b := new(Builder)
+	b.Title("title").
+		HomePage("https://example.org/").
+		FeedURL("https://example.org/feed.json").
+		NextURL("https://example.org/feed.json?page=2").
+		Description("description").
+		UserComment("comment").
+		Icon("https://example.org/icon.png").
+		Favicon("https://example.org/favicon.png").
+		Author(Author{Name: "name"}).
+		Hub("WebSub", "https://hub.example/").
+		AddItem(Item{URL: "https://example.org/1", ContentText: "text"}).
+		WithTag("a", "b").
+		WithAttachment(Attachment{URL: "https://example.org/1.mp3", MIMEType: "audio/mpeg"}).
+		AddItem(Item{
+			ID:          "2",
+			ContentHTML: "<p>html</p>",
+			Attachments: []Attachment{{URL: "https://example.org/2.mp3", MIMEType: "audio/mpeg"}},
+		})
+	got, err := b.Build()
+	if err != nil {
+		t.Fatalf("Build() = %v, want nil", err)
+	}
+	want := &Feed{
+		Version:     Version,
+		Title:       "title",
+		HomePageURL: "https://example.org/",
+		FeedURL:     "https://example.org/feed.json",
+		NextURL:     "https://example.org/feed.json?page=2",
+		Description: "description",
+		UserComment: "comment",
+		Icon:        "https://example.org/icon.png",
+		Favicon:     "https://example.org/favicon.png",
+		Author:      &Author{Name: "name"},
+		Hubs:        []Hub{{Type: "WebSub", URL: "https://hub.example/"}},
+		Items: []Item{
+			{
+				ID:          "https://example.org/1",
+				URL:         "https://example.org/1",
+				ContentText: "text",
+				Tags:        []string{"a", "b"},
+				Attachments: []Attachment{{URL: "https://example.org/1.mp3", MIMEType: "audio/mpeg"}},
+			},
+			{
+				ID:          "2",
+				ContentHTML: "<p>html</p>",
+				Attachments: []Attachment{{URL: "https://example.org/2.mp3", MIMEType: "audio/mpeg"}},
+			},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Build() = %#v, want %#v", got, want)
+	}
+
+	// Later changes to b must not affect the built feed.
+	b.AddItem(Item{ID: "3", ContentText: "text"})
+	if len(got.Items) != 2 {
+		t.Errorf("AddItem after Build changed built feed")
+	}
+}
+
+func TestBuilderExpired(t *testing.T) {
+	f, err := new(Builder).Title("title").Expired().Build()
+	if err != nil {
+		t.Fatalf("Build() = %v, want nil", err)
+	}
+	if !f.Expired {
+		t.Errorf("Build() => Expired = false, want true")
+	}
+}
+
+func TestBuilderInvalid(t *testing.T) {
+	_, err := new(Builder).Build() // no title
+	if err == nil {
+		t.Fatalf("Build() = nil, want error")
+	}
+	if _, ok := err.(BuildErrors); ok {
+		t.Errorf("Build() = %T, want validation error", err)
+	}
+}
+
+func TestBuilderErrors(t *testing.T) {
+	b := new(Builder)
+	b.WithTag("x").
+		WithAttachment(Attachment{}).
+		Title("title").
+		HomePage("relative/path").
+		FeedURL("https://example.org/feed.json").
+		NextURL("https://example.org/feed.json").
+		Icon("%zz").
+		Author(Author{}).
+		Hub("", "https://hub.example/").
+		AddItem(Item{ContentText: "text"}).
+		AddItem(Item{ID: "a", URL: "::", Author: &Author{}}).
+		AddItem(Item{ID: "a", ContentText: "text"}).
+		WithTag("").
+		WithAttachment(Attachment{}).
+		WithAttachment(Attachment{URL: "rel", MIMEType: "text/plain"})
+	_, err := b.Build()
+	errs, ok := err.(BuildErrors)
+	if !ok {
+		t.Fatalf("Build() = %v, want BuildErrors", err)
+	}
+	want := []string{
+		"jsonfeed: tags: no item added",
+		"jsonfeed: attachments: no item added",
+		"jsonfeed: home_page_url: not an absolute URL: relative/path",
+		`jsonfeed: icon: parse "%zz": invalid URL escape "%zz"`,
+		"jsonfeed: author: no name or url or avatar",
+		"jsonfeed: hubs: no type",
+		"jsonfeed: item 0: id: no id or url",
+		`jsonfeed: item 1: url: parse "::": missing protocol scheme`,
+		"jsonfeed: item 1: content_text: no content_html or content_text",
+		"jsonfeed: item 1: author: no name or url or avatar",
+		"jsonfeed: item 2: id: duplicate id a",
+		"jsonfeed: item 2: tags: empty tag",
+		"jsonfeed: item 2: attachments: no url",
+		"jsonfeed: item 2: attachments: no mime_type",
+		"jsonfeed: item 2: attachments: not an absolute URL: rel",
+		"jsonfeed: next_url: same as feed_url",
+	}
+	var got []string
+	for _, e := range errs {
+		got = append(got, e.Error())
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Build() errors = %q, want %q", got, want)
+	}
+	if errs.Error() == "" {
+		t.Errorf("BuildErrors.Error() = empty")
+	}
+	if errors.Unwrap(errs[0]) == nil {
+		t.Errorf("BuildError.Unwrap() = nil, want error")
+	}
+}
+
+func TestBuilderNextURL(t *testing.T) {
+	// The check does not depend on the order of the calls.
+	b := new(Builder)
+	b.Title("title").
+		NextURL("https://example.org/feed.json").
+		FeedURL("https://example.org/feed.json")
+	_, err := b.Build()
+	if errs, ok := err.(BuildErrors); !ok || len(errs) != 1 || errs[0].Field != "next_url" {
+		t.Errorf("Build() = %v, want next_url error", err)
+	}
+	b.FeedURL("https://example.org/feed.json?page=1")
+	if _, err := b.Build(); err != nil {
+		t.Errorf("Build() after fixing feed_url = %v, want nil", err)
+	}
+}
+
+func TestBuilderFillAttachments(t *testing.T) {
+	b := new(Builder)
+	b.Title("title").
+		AddItem(Item{ID: "1", ContentText: "text"}).
+		WithAttachment(Attachment{URL: "https://example.org/0.mp3"}).
+		FillAttachments(func(a *Attachment) error {
+			a.MIMEType = "audio/mpeg"
+			a.SizeInBytes = int64(len(a.URL))
+			return nil
+		}).
+		WithAttachment(Attachment{URL: "https://example.org/1.mp3"})
+	_, err := b.Build()
+	if errs, ok := err.(BuildErrors); !ok || len(errs) != 1 {
+		t.Fatalf("Build() = %v, want one error for the first attachment", err)
+	}
+
+	b = new(Builder)
+	b.Title("title").
+		FillAttachments(func(a *Attachment) error {
+			a.MIMEType = "audio/mpeg"
+			a.SizeInBytes = int64(len(a.URL))
+			return nil
+		}).
+		AddItem(Item{
+			ID:          "1",
+			ContentText: "text",
+			Attachments: []Attachment{{URL: "https://example.org/1.mp3"}},
+		})
+	f, err := b.Build()
+	if err != nil {
+		t.Fatalf("Build() = %v, want nil", err)
+	}
+	want := Attachment{URL: "https://example.org/1.mp3", MIMEType: "audio/mpeg", SizeInBytes: 25}
+	if got := f.Items[0].Attachments[0]; got != want {
+		t.Errorf("attachment = %+v, want %+v", got, want)
+	}
+
+	b = new(Builder)
+	b.Title("title").
+		FillAttachments(func(a *Attachment) error { return errors.New("no file") }).
+		AddItem(Item{ID: "1", ContentText: "text"}).
+		WithAttachment(Attachment{URL: "https://example.org/1.mp3", MIMEType: "audio/mpeg"})
+	_, err = b.Build()
+	if err == nil || err.Error() != "jsonfeed: item 0: attachments: no file" {
+		t.Errorf("Build() = %v, want no file error", err)
+	}
+}
+
+func TestBuilderGenerateIDs(t *testing.T) {
+	b := new(Builder)
+	b.Title("title").
+		AddItem(Item{URL: "https://example.org/0", ContentText: "zero"}).
+		GenerateIDs(URLIDs).
+		AddItem(Item{URL: "https://EXAMPLE.org/1", ContentText: "one"}).
+		AddItem(Item{ID: "two", URL: "https://example.org/2", ContentText: "two"})
+	f, err := b.Build()
+	if err != nil {
+		t.Fatalf("Build() = %v, want nil", err)
+	}
+	want := []string{"https://example.org/0", "https://example.org/1", "two"}
+	for i, id := range want {
+		if f.Items[i].ID != id {
+			t.Errorf("item %d id = %q, want %q", i, f.Items[i].ID, id)
+		}
+	}
+
+	b = new(Builder)
+	b.Title("title").
+		GenerateIDs(URLIDs).
+		AddItem(Item{ContentText: "text"})
+	_, err = b.Build()
+	errs, ok := err.(BuildErrors)
+	if !ok || len(errs) != 2 || errs[0].Error() != "jsonfeed: item 0: id: no url for id" {
+		t.Errorf("Build() = %v, want id errors", err)
+	}
+}
@@ -0,0 +1,67 @@
+/*
+Command jsonfeed works with JSON Feed files.
+
+Usage:
+
+	jsonfeed validate [-format text|json] [file ...]
+
+Validate checks each file, or standard input if there are none,
+or for the name "-".
+It reports each problem with its location,
+as a line and column in the file
+and as a path to the value, such as items[3].date_published.
+Problems that make a feed invalid are errors;
+likely mistakes found by jsonfeed.Lint are warnings.
+Validate exits with status 1 if there are any errors.
+
+Flags:
+
+	-format string  output format, text or json (default "text")
+
+In JSON format, the output is an array of objects with fields
+file, line, column, path, severity, and message.
+*/
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+)
+
+// errInvalid reports that some feed was invalid.
+// The problems have already been printed.
+var errInvalid = errors.New("invalid feed")
+
+func main() {
+	err := run(os.Args[1:], os.Stdin, os.Stdout)
+	if err == flag.ErrHelp {
+		os.Exit(2)
+	}
+	if err == errInvalid {
+		os.Exit(1)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func usage(w io.Writer) {
+	fmt.Fprintln(w, "usage: jsonfeed validate [-format text|json] [file ...]")
+}
+
+func run(args []string, stdin io.Reader, stdout io.Writer) error {
+	if len(args) == 0 {
+		usage(os.Stderr)
+		return flag.ErrHelp
+	}
+	switch args[0] {
+	case "validate":
+		return validate(args[1:], stdin, stdout)
+	}
+	usage(os.Stderr)
+	return flag.ErrHelp
+}
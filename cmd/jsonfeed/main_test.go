@@ -0,0 +1,191 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const badFeed = `{
+  "version": "https://jsonfeed.org/version/1",
+  "expired": true,
+  "hubs": [{"type": "WebSub", "url": "https://example.org/hub"}],
+  "items": [
+    {"id": "a", "content_text": "a"},
+    {"id": "a"}
+  ]
+}
+`
+
+const goodFeed = `{"version": "https://jsonfeed.org/version/1", "title": "t", "items": []}`
+
+func writeFile(t *testing.T, name, data string) {
+	t.Helper()
+	if err := os.WriteFile(name, []byte(data), 0666); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	dir := t.TempDir()
+	bad := filepath.Join(dir, "bad.json")
+	good := filepath.Join(dir, "good.json")
+	writeFile(t, bad, badFeed)
+	writeFile(t, good, goodFeed)
+
+	var stdout bytes.Buffer
+	err := run([]string{"validate", good, bad}, nil, &stdout)
+	if err != errInvalid {
+		t.Errorf("run() = %v, want errInvalid", err)
+	}
+	want := bad + `:1:1: error: title: no title
+` + bad + `:7:12: error: items[1].id: duplicate id a
+` + bad + `:7:5: error: items[1]: no content_html or content_text in item a
+` + bad + `:4:11: warning: hubs: expired feed lists hubs
+`
+	if got := stdout.String(); got != want {
+		t.Errorf("run() wrote:\n%s\nwant:\n%s", got, want)
+	}
+
+	stdout.Reset()
+	err = run([]string{"validate", "-format=json"}, strings.NewReader(goodFeed), &stdout)
+	if err != nil || stdout.String() != "[]\n" {
+		t.Errorf("run(good) = %v, wrote %q, want nil and []", err, stdout.String())
+	}
+
+	// Warnings alone are not an error.
+	stdout.Reset()
+	lint := strings.Replace(goodFeed, `"items"`, `"expired": true, "hubs": [{"type": "t", "url": "u"}], "items"`, 1)
+	if err := run([]string{"validate", "-"}, strings.NewReader(lint), &stdout); err != nil {
+		t.Errorf("run(lint) = %v, want nil", err)
+	}
+	if !strings.HasPrefix(stdout.String(), "<stdin>:1:") {
+		t.Errorf("run(lint) wrote %q, want warning", stdout.String())
+	}
+}
+
+func TestValidateJSON(t *testing.T) {
+	cases := []struct {
+		in   string
+		want problem
+	}{
+		{`{"items": [`, problem{Line: 1, Column: 11, Message: "unexpected end of JSON input"}},
+		{"{\n\"title\": 5}", problem{Line: 2, Column: 10, Path: "title", Message: "cannot use JSON number as string"}},
+		{`{"items": [{"id": "a"}, {"id": "b", "title": 5}]}`, problem{Line: 1, Column: 46, Path: "items[1].title", Message: "cannot use JSON number as string"}},
+		{`{"items": [{"id": "a", "tags": ["x", {}]}]}`, problem{Line: 1, Column: 38, Path: "items[0].tags[1]", Message: "cannot use JSON object as string"}},
+		{`[]`, problem{Line: 1, Column: 1, Path: "", Message: "cannot use JSON array as object"}},
+		{`{"items": [{"date_published": "soon"}]}`, problem{Line: 1, Column: 31, Path: "items[0].date_published"}},
+		{`{"items": [{"id": "1", "attachments": [{"url": "u", "size_in_bytes": "big"}]}]}`, problem{Line: 1, Column: 70, Path: "items[0].attachments[0].size_in_bytes", Message: "cannot use JSON string as int64"}},
+		{`{"items": [{"id": "1", "attachments": [{"url": "u", "size_in_bytes": -1}]}]}`, problem{Line: 1, Column: 70, Path: "items[0].attachments[0].size_in_bytes", Message: "cannot use JSON number -1 as int64"}},
+		{`{"items": [{"id": "1", "date_published": "soon"}, 1]}`, problem{Line: 1, Column: 1, Path: ""}},
+		{`{"title": "<t>"}`, problem{Line: 1, Column: 1, Path: "version", Message: "no version"}},
+	}
+	for _, test := range cases {
+		var stdout bytes.Buffer
+		err := run([]string{"validate", "-format", "json"}, strings.NewReader(test.in), &stdout)
+		if err != errInvalid {
+			t.Errorf("run(%s) = %v, want errInvalid", test.in, err)
+		}
+		var probs []problem
+		if err := json.Unmarshal(stdout.Bytes(), &probs); err != nil || len(probs) == 0 {
+			t.Errorf("run(%s) wrote %s", test.in, stdout.Bytes())
+			continue
+		}
+		got := probs[0]
+		if test.want.Message == "" {
+			got.Message = ""
+		}
+		test.want.File, test.want.Severity = "<stdin>", "error"
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("run(%s) = %+v, want %+v", test.in, got, test.want)
+		}
+	}
+}
+
+func TestLocator(t *testing.T) {
+	l := newLocator([]byte("{\"a\": [1, {\"b\": true}],\n \"c\": null}"))
+	cases := []struct {
+		path      string
+		line, col int
+	}{
+		{"", 1, 1},
+		{"a", 1, 7},
+		{"a[1]", 1, 11},
+		{"a[1].b", 1, 17},
+		{"a[1].x", 1, 11},
+		{"a[2]", 1, 7},
+		{"c", 2, 7},
+		{"x.y", 1, 1},
+	}
+	for _, test := range cases {
+		line, col := l.pos(l.find(test.path))
+		if line != test.line || col != test.col {
+			t.Errorf("find(%s) at %d:%d, want %d:%d", test.path, line, col, test.line, test.col)
+		}
+	}
+	if got, want := l.paths, []string{"", "a", "a[0]", "a[1]", "a[1].b", "c"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("paths = %q, want %q", got, want)
+	}
+
+	// Malformed documents are located as far as they go.
+	cases2 := []struct {
+		in   string
+		want []string
+	}{
+		{`{"a": [1, }`, []string{"", "a", "a[0]"}},
+		{`{"a" 1}`, []string{""}},
+		{`{"a": tru`, []string{""}},
+		{`{"a": {"b": 1`, []string{"", "a", "a.b"}},
+	}
+	for _, test := range cases2 {
+		if got := newLocator([]byte(test.in)).paths; !reflect.DeepEqual(got, test.want) {
+			t.Errorf("newLocator(%s).paths = %q, want %q", test.in, got, test.want)
+		}
+	}
+
+	te := &json.UnmarshalTypeError{Value: "number", Field: "tags.1", Offset: 3}
+	if path, off := l.match(te); path != "tags[1]" || off != 3 {
+		t.Errorf("match(%v) = %q, %d, want tags[1], 3", te, path, off)
+	}
+
+	for _, field := range []string{"", "title", "tags.1", "1.a.2"} {
+		got := fieldPath(field)
+		want := map[string]string{"": "", "title": "title", "tags.1": "tags[1]", "1.a.2": "1.a[2]"}[field]
+		if got != want {
+			t.Errorf("fieldPath(%q) = %q, want %q", field, got, want)
+		}
+	}
+}
+
+type errWriter struct{}
+
+func (errWriter) Write([]byte) (int, error) { return 0, errors.New("write failed") }
+
+func TestRunBad(t *testing.T) {
+	dir := t.TempDir()
+	cases := [][]string{
+		{},
+		{"bogus"},
+		{"validate", "-bogus"},
+		{"validate", "-format", "xml"},
+		{"validate", filepath.Join(dir, "missing.json")},
+	}
+	for _, args := range cases {
+		if err := run(args, strings.NewReader(goodFeed), new(bytes.Buffer)); err == nil || err == errInvalid {
+			t.Errorf("run(%q) = %v, want error", args, err)
+		}
+	}
+	if err := run(nil, nil, nil); err != flag.ErrHelp {
+		t.Errorf("run() = %v, want flag.ErrHelp", err)
+	}
+	err := run([]string{"validate", "-format=json"}, strings.NewReader(goodFeed), errWriter{})
+	if err == nil || err == errInvalid {
+		t.Errorf("run(failed write) = %v, want error", err)
+	}
+}
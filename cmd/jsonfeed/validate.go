@@ -0,0 +1,272 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/kr/jsonfeed"
+)
+
+// A problem is one problem found in a feed.
+type problem struct {
+	File     string `json:"file"`
+	Line     int    `json:"line"`
+	Column   int    `json:"column"`
+	Path     string `json:"path"`
+	Severity string `json:"severity"` // "error" or "warning"
+	Message  string `json:"message"`
+}
+
+func (p *problem) String() string {
+	s := p.File + ":" + strconv.Itoa(p.Line) + ":" + strconv.Itoa(p.Column) + ": " + p.Severity + ": "
+	if p.Path != "" {
+		s += p.Path + ": "
+	}
+	return s + p.Message
+}
+
+func validate(args []string, stdin io.Reader, stdout io.Writer) error {
+	fs := flag.NewFlagSet("validate", flag.ContinueOnError)
+	format := fs.String("format", "text", "output `format`, text or json")
+	fs.Usage = func() {
+		usage(fs.Output())
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *format != "text" && *format != "json" {
+		return errors.New("jsonfeed: unknown format " + *format)
+	}
+	names := fs.Args()
+	if len(names) == 0 {
+		names = []string{"-"}
+	}
+	probs := []*problem{}
+	for _, name := range names {
+		var b []byte
+		var err error
+		if name == "-" {
+			name = "<stdin>"
+			b, err = io.ReadAll(stdin)
+		} else {
+			b, err = os.ReadFile(name)
+		}
+		if err != nil {
+			return err
+		}
+		probs = append(probs, check(name, b)...)
+	}
+
+	if *format == "json" {
+		enc := json.NewEncoder(stdout)
+		enc.SetEscapeHTML(false)
+		enc.SetIndent("", "\t")
+		if err := enc.Encode(probs); err != nil {
+			return err
+		}
+	} else {
+		for _, p := range probs {
+			fmt.Fprintln(stdout, p)
+		}
+	}
+	for _, p := range probs {
+		if p.Severity == "error" {
+			return errInvalid
+		}
+	}
+	return nil
+}
+
+// unvalidated is a Feed with no UnmarshalJSON method,
+// for decoding invalid feeds.
+type unvalidated jsonfeed.Feed
+
+// check returns the problems in the feed b,
+// read from the named file.
+func check(name string, b []byte) []*problem {
+	l := newLocator(b)
+	var probs []*problem
+	add := func(path string, off int64, severity, msg string) {
+		line, col := l.pos(off)
+		probs = append(probs, &problem{name, line, col, path, severity, msg})
+	}
+
+	var f jsonfeed.Feed
+	err := jsonfeed.UnmarshalOptions{}.Unmarshal(b, &f)
+	var (
+		se *json.SyntaxError
+		te *json.UnmarshalTypeError
+		fe *jsonfeed.FieldError
+	)
+	switch {
+	case errors.As(err, &se):
+		add("", se.Offset-1, "error", se.Error())
+		return probs
+	case errors.As(err, &te):
+		path, off := l.match(te)
+		add(path, off, "error", "cannot use JSON "+te.Value+" as "+typeName(te.Type))
+		return probs
+	case err != nil:
+		// The feed may be well-formed but invalid.
+		// Decode it without validation
+		// so Validate can find all the problems.
+		// If that fails too, report the original error,
+		// such as a bad date.
+		if json.Unmarshal(b, (*unvalidated)(&f)) != nil {
+			if errors.As(err, &fe) {
+				add(fe.Path, l.find(fe.Path), "error", fe.Err.Error())
+			} else {
+				add("", 0, "error", err.Error())
+			}
+			return probs
+		}
+	}
+	for _, p := range jsonfeed.Validate(&f) {
+		add(p.Path, l.find(p.Path), "error", p.Err.Error())
+	}
+	for _, p := range jsonfeed.Lint(&f) {
+		add(p.Path, l.find(p.Path), "warning", p.Err.Error())
+	}
+	return probs
+}
+
+// A locator finds values in a JSON document by path,
+// such as items[3].title,
+// and gives their positions as line and column.
+type locator struct {
+	src   []byte
+	paths []string         // in document order
+	start map[string]int64 // offset of each value
+	kind  map[string]string
+}
+
+func newLocator(src []byte) *locator {
+	l := &locator{src: src, start: make(map[string]int64), kind: make(map[string]string)}
+	dec := json.NewDecoder(bytes.NewReader(src))
+	l.walk(dec, "") // a malformed document is located as far as possible
+	return l
+}
+
+// walk records the value read next from dec,
+// which has the given path, and any values inside it.
+func (l *locator) walk(dec *json.Decoder, path string) error {
+	off := dec.InputOffset()
+	for off < int64(len(l.src)) && strings.IndexByte(" \t\r\n,:", l.src[off]) >= 0 {
+		off++
+	}
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	l.paths = append(l.paths, path)
+	l.start[path] = off
+	switch tok := tok.(type) {
+	case json.Delim:
+		if tok == '{' {
+			l.kind[path] = "object"
+			for dec.More() {
+				key, err := dec.Token()
+				if err != nil {
+					return err
+				}
+				sub := key.(string)
+				if path != "" {
+					sub = path + "." + sub
+				}
+				if err := l.walk(dec, sub); err != nil {
+					return err
+				}
+			}
+		} else {
+			l.kind[path] = "array"
+			for i := 0; dec.More(); i++ {
+				if err := l.walk(dec, path+"["+strconv.Itoa(i)+"]"); err != nil {
+					return err
+				}
+			}
+		}
+		_, err = dec.Token() // closing delimiter
+	case string:
+		l.kind[path] = "string"
+	case float64:
+		l.kind[path] = "number"
+	case bool:
+		l.kind[path] = "bool"
+	}
+	return err
+}
+
+// find returns the offset of the value with the given path,
+// or of its closest enclosing value if it is missing.
+func (l *locator) find(path string) int64 {
+	for path != "" {
+		if off, ok := l.start[path]; ok {
+			return off
+		}
+		path = path[:max(strings.LastIndexByte(path, '.'), strings.LastIndexByte(path, '['), 0)]
+	}
+	return l.start[""]
+}
+
+// typeName returns a name for t to use in messages.
+// Feed and Item decode through unexported types,
+// so structs are called objects.
+func typeName(t reflect.Type) string {
+	if t.Kind() == reflect.Struct {
+		return "object"
+	}
+	return t.String()
+}
+
+// match returns the path and offset of the value
+// described by te.
+// Since the error's Offset and Field are relative to the item,
+// for errors inside items,
+// it looks for the first value of the wrong kind
+// whose path ends with te.Field.
+func (l *locator) match(te *json.UnmarshalTypeError) (string, int64) {
+	field := fieldPath(te.Field)
+	kind, _, _ := strings.Cut(te.Value, " ") // such as "number -1"
+	for _, p := range l.paths {
+		if (p == field || strings.HasSuffix(p, "."+field)) && l.kind[p] == kind {
+			return p, l.start[p]
+		}
+	}
+	return field, te.Offset
+}
+
+// fieldPath converts a Field from encoding/json,
+// such as tags.1, to a path, such as tags[1].
+func fieldPath(field string) string {
+	var b strings.Builder
+	for i, s := range strings.Split(field, ".") {
+		if _, err := strconv.Atoi(s); err == nil && i > 0 {
+			b.WriteString("[" + s + "]")
+			continue
+		}
+		if i > 0 {
+			b.WriteByte('.')
+		}
+		b.WriteString(s)
+	}
+	return b.String()
+}
+
+// pos returns the line and column, counting from 1,
+// of the byte at offset off.
+func (l *locator) pos(off int64) (line, col int) {
+	off = min(max(off, 0), int64(len(l.src)))
+	before := l.src[:off]
+	line = 1 + bytes.Count(before, []byte("\n"))
+	col = len(before) - bytes.LastIndexByte(before, '\n')
+	return line, col
+}
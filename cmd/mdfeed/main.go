@@ -0,0 +1,92 @@
+/*
+Command mdfeed writes a JSON Feed built from a directory of Markdown files.
+
+Usage:
+
+	mdfeed [flags] dir
+
+The feed is written to standard output, or to the file named by -o.
+See package github.com/kr/jsonfeed/mdfeed
+for the format of the Markdown files.
+
+Flags:
+
+	-title string        feed title (required)
+	-home string         home page URL
+	-feed string         feed URL
+	-base string         base URL for item URLs
+	-description string  feed description
+	-author string       feed author name
+	-o file              output file
+*/
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/kr/jsonfeed"
+	"github.com/kr/jsonfeed/mdfeed"
+)
+
+func main() {
+	err := run(os.Args[1:], os.Stdout)
+	if err == flag.ErrHelp {
+		os.Exit(2)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run(args []string, stdout io.Writer) error {
+	fs := flag.NewFlagSet("mdfeed", flag.ContinueOnError)
+	var (
+		c      mdfeed.Config
+		author string
+		out    string
+	)
+	fs.StringVar(&c.Title, "title", "", "feed title (required)")
+	fs.StringVar(&c.HomePageURL, "home", "", "home page URL")
+	fs.StringVar(&c.FeedURL, "feed", "", "feed URL")
+	fs.StringVar(&c.BaseURL, "base", "", "base URL for item URLs")
+	fs.StringVar(&c.Description, "description", "", "feed description")
+	fs.StringVar(&author, "author", "", "feed author name")
+	fs.StringVar(&out, "o", "", "output `file`")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: mdfeed [flags] dir")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return flag.ErrHelp
+	}
+	if c.Title == "" {
+		return errors.New("mdfeed: -title is required")
+	}
+	if author != "" {
+		c.Author = &jsonfeed.Author{Name: author}
+	}
+	f, err := mdfeed.Build(os.DirFS(fs.Arg(0)), &c)
+	if err != nil {
+		return err
+	}
+	b, err := json.MarshalIndent(f, "", "\t")
+	if err != nil {
+		return err
+	}
+	b = append(b, '\n')
+	if out != "" {
+		return os.WriteFile(out, b, 0666)
+	}
+	_, err = stdout.Write(b)
+	return err
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/kr/jsonfeed"
+)
+
+func writeFile(t *testing.T, name, data string) {
+	t.Helper()
+	if err := os.WriteFile(name, []byte(data), 0666); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRun(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "a.md"), "---\ntitle: A\ndate: 2017-05-17\n---\nHello.")
+	var stdout bytes.Buffer
+	err := run([]string{"-title", "Blog", "-author", "kr", "-base", "https://example.org/", dir}, &stdout)
+	if err != nil {
+		t.Fatalf("run() = %v, want nil", err)
+	}
+	var f jsonfeed.Feed
+	if err := json.Unmarshal(stdout.Bytes(), &f); err != nil {
+		t.Fatalf("output is not a valid feed: %v\n%s", err, stdout.Bytes())
+	}
+	if len(f.Items) != 1 || f.Items[0].ID != "https://example.org/a" || f.Author.Name != "kr" {
+		t.Errorf("run() wrote %s", stdout.Bytes())
+	}
+
+	out := filepath.Join(dir, "feed.json")
+	err = run([]string{"-title", "Blog", "-o", out, dir}, &stdout)
+	if err != nil {
+		t.Fatalf("run(-o) = %v, want nil", err)
+	}
+	if _, err := os.Stat(out); err != nil {
+		t.Errorf("run(-o) did not write output: %v", err)
+	}
+}
+
+func TestRunBad(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "a.md"), "no front matter")
+	cases := [][]string{
+		{"-bogus"},
+		{},
+		{"-title", "t", "a", "b"},
+		{t.TempDir()}, // no title
+		{"-title", "t", dir},
+		{"-title", "t", "-o", filepath.Join(dir, "missing", "feed.json"), t.TempDir()},
+	}
+
+	for _, args := range cases {
+		err := run(args, new(bytes.Buffer))
+		if err == nil {
+			t.Errorf("run(%q) = nil, want error", args)
+		}
+	}
+}
@@ -0,0 +1,459 @@
+package jsonfeed
+
+import (
+	"html"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// FillContent sets whichever of ContentHTML and ContentText
+// is empty by converting the other one.
+// If both are present, or both are empty, it does nothing.
+func (t *Item) FillContent() {
+	switch {
+	case t.ContentHTML == "" && t.ContentText != "":
+		t.ContentHTML = TextToHTML(t.ContentText)
+	case t.ContentText == "" && t.ContentHTML != "":
+		t.ContentText = HTMLToText(t.ContentHTML)
+	}
+}
+
+// HTMLToText renders the HTML fragment s as readable plain text.
+// Block elements become paragraphs,
+// list items are prefixed with a bullet or number,
+// and the contents of pre elements are kept verbatim.
+// The target of each link is appended to its text
+// as a footnote reference such as [1],
+// and the footnotes are listed at the end,
+// unless the link text is the URL itself.
+// Script and style elements are dropped.
+func HTMLToText(s string) string {
+	return htmlToText(s, true)
+}
+
+// htmlToText is HTMLToText,
+// but it omits link footnotes if footnotes is false.
+func htmlToText(s string, footnotes bool) string {
+	w := &textWriter{noLinks: !footnotes}
+	for s != "" {
+		if s[0] != '<' {
+			i := strings.IndexByte(s, '<')
+			if i < 0 {
+				i = len(s)
+			}
+			w.text(html.UnescapeString(s[:i]))
+			s = s[i:]
+			continue
+		}
+		tok, rest, ok := nextTag(s)
+		if !ok {
+			w.text("<")
+			s = s[1:]
+			continue
+		}
+		s = rest
+		if tok.name == "" {
+			continue // comment, doctype, or processing instruction
+		}
+		if tok.name == "script" || tok.name == "style" {
+			if !tok.end {
+				s = skipElement(s, tok.name)
+			}
+			continue
+		}
+		w.tag(tok)
+	}
+	return w.String()
+}
+
+// tagToken is a start or end tag read by nextTag.
+type tagToken struct {
+	name string // lower case; empty for comments and the like
+	end  bool
+	href string // value of the href attribute, for start tags
+}
+
+// nextTag reads the tag at the start of s,
+// which must begin with '<'.
+// It reports false if s does not begin with something
+// that looks like markup, in which case the '<' is text.
+func nextTag(s string) (tok tagToken, rest string, ok bool) {
+	if strings.HasPrefix(s, "<!--") {
+		i := strings.Index(s[4:], "-->")
+		if i < 0 {
+			return tok, "", true
+		}
+		return tok, s[4+i+3:], true
+	}
+	i := 1
+	if i < len(s) && (s[i] == '!' || s[i] == '?') {
+		j := strings.IndexByte(s, '>')
+		if j < 0 {
+			return tok, "", true
+		}
+		return tok, s[j+1:], true
+	}
+	if i < len(s) && s[i] == '/' {
+		tok.end = true
+		i++
+	}
+	j := i
+	for j < len(s) && isTagNameByte(s[j]) {
+		j++
+	}
+	if j == i {
+		return tok, s, false
+	}
+	tok.name = strings.ToLower(s[i:j])
+	for j < len(s) && s[j] != '>' {
+		c := s[j]
+		switch {
+		case c == '"' || c == '\'':
+			k := strings.IndexByte(s[j+1:], c)
+			if k < 0 {
+				return tok, "", true
+			}
+			j += 1 + k + 1
+		case isTagNameByte(c):
+			k := j
+			for k < len(s) && (isTagNameByte(s[k]) || s[k] == '-') {
+				k++
+			}
+			name := strings.ToLower(s[j:k])
+			val, n := attrValue(s[k:])
+			if name == "href" {
+				tok.href = html.UnescapeString(val)
+			}
+			j = k + n
+		default:
+			j++
+		}
+	}
+	if j < len(s) {
+		j++ // '>'
+	}
+	return tok, s[j:], true
+}
+
+// attrValue reads an optional "=value" at the start of s.
+// It returns the value and the number of bytes consumed.
+func attrValue(s string) (string, int) {
+	i := 0
+	for i < len(s) && isSpaceByte(s[i]) {
+		i++
+	}
+	if i == len(s) || s[i] != '=' {
+		return "", 0
+	}
+	i++
+	for i < len(s) && isSpaceByte(s[i]) {
+		i++
+	}
+	if i < len(s) && (s[i] == '"' || s[i] == '\'') {
+		q := s[i]
+		k := strings.IndexByte(s[i+1:], q)
+		if k < 0 {
+			return s[i+1:], len(s)
+		}
+		return s[i+1 : i+1+k], i + 1 + k + 1
+	}
+	j := i
+	for j < len(s) && !isSpaceByte(s[j]) && s[j] != '>' {
+		j++
+	}
+	return s[i:j], j
+}
+
+// skipElement returns the part of s after the end tag
+// for the named element, or "" if there is none.
+func skipElement(s, name string) string {
+	lower := strings.ToLower(s)
+	i := strings.Index(lower, "</"+name)
+	if i < 0 {
+		return ""
+	}
+	j := strings.IndexByte(s[i:], '>')
+	if j < 0 {
+		return ""
+	}
+	return s[i+j+1:]
+}
+
+func isTagNameByte(c byte) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9'
+}
+
+func isSpaceByte(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f'
+}
+
+// textWriter accumulates the plain text rendering
+// of an HTML document for HTMLToText.
+type textWriter struct {
+	buf    strings.Builder
+	breaks int    // pending newlines, written before the next text
+	space  bool   // pending space, written before the next text
+	prefix string // pending list item marker
+	pre    int    // depth of pre elements
+	lists  []int  // open lists; -1 for unordered, else the last number used
+
+	noLinks  bool
+	inLink   bool
+	href     string
+	linkText strings.Builder
+	links    []string
+}
+
+func (w *textWriter) tag(tok tagToken) {
+	switch tok.name {
+	case "br":
+		w.newline(1)
+	case "p", "div", "h1", "h2", "h3", "h4", "h5", "h6",
+		"blockquote", "table", "section", "article",
+		"header", "footer", "figure", "hr":
+		w.newline(2)
+	case "pre":
+		w.newline(2)
+		if tok.end {
+			w.pre--
+		} else {
+			w.pre++
+		}
+	case "tr", "dt", "dd":
+		w.newline(1)
+	case "td", "th":
+		w.space = true
+	case "ul", "ol":
+		if tok.end && len(w.lists) > 0 {
+			w.lists = w.lists[:len(w.lists)-1]
+		} else if !tok.end {
+			n := 0
+			if tok.name == "ul" {
+				n = -1
+			}
+			w.lists = append(w.lists, n)
+		}
+		if len(w.lists) > 0 {
+			w.newline(1)
+		} else {
+			w.newline(2)
+		}
+	case "li":
+		w.newline(1)
+		if tok.end || len(w.lists) == 0 {
+			break
+		}
+		indent := strings.Repeat("  ", len(w.lists)-1)
+		n := &w.lists[len(w.lists)-1]
+		if *n < 0 {
+			w.prefix = indent + "- "
+		} else {
+			*n++
+			w.prefix = indent + strconv.Itoa(*n) + ". "
+		}
+	case "a":
+		if w.noLinks {
+			break
+		}
+		if tok.end {
+			w.endLink()
+		} else {
+			w.endLink() // nested links are not allowed; close the old one
+			w.inLink = true
+			w.href = tok.href
+			w.linkText.Reset()
+		}
+	}
+}
+
+func (w *textWriter) endLink() {
+	if !w.inLink {
+		return
+	}
+	w.inLink = false
+	href := strings.TrimSpace(w.href)
+	text := strings.TrimSpace(w.linkText.String())
+	if href == "" || strings.HasPrefix(href, "#") ||
+		strings.HasPrefix(strings.ToLower(href), "javascript:") ||
+		text == href || "mailto:"+text == href {
+		return
+	}
+	n := 0
+	for i, u := range w.links {
+		if u == href {
+			n = i + 1
+		}
+	}
+	if n == 0 {
+		w.links = append(w.links, href)
+		n = len(w.links)
+	}
+	// A link with no text may start a block,
+	// but a space at the end of the text goes after the marker.
+	space := w.space
+	w.space = false
+	w.flush()
+	w.space = space
+	w.buf.WriteString("[" + strconv.Itoa(n) + "]")
+}
+
+// newline requests at least n newlines before the next text.
+func (w *textWriter) newline(n int) {
+	if n > w.breaks {
+		w.breaks = n
+	}
+}
+
+func (w *textWriter) text(s string) {
+	if w.inLink {
+		w.linkText.WriteString(s)
+	}
+	if w.pre > 0 {
+		if s != "" {
+			w.flush()
+			w.buf.WriteString(s)
+		}
+		return
+	}
+	for _, r := range s {
+		if unicode.IsSpace(r) {
+			w.space = true
+			continue
+		}
+		w.flush()
+		w.buf.WriteRune(r)
+	}
+}
+
+// flush writes any pending separators before text.
+func (w *textWriter) flush() {
+	if w.buf.Len() > 0 {
+		if w.breaks > 0 {
+			w.buf.WriteString(strings.Repeat("\n", w.breaks))
+		} else if w.space && w.prefix == "" {
+			w.buf.WriteByte(' ')
+		}
+	}
+	w.buf.WriteString(w.prefix)
+	w.breaks = 0
+	w.space = false
+	w.prefix = ""
+}
+
+func (w *textWriter) String() string {
+	w.endLink()
+	s := strings.TrimRightFunc(w.buf.String(), unicode.IsSpace)
+	if len(w.links) > 0 {
+		s += "\n"
+		for i, u := range w.links {
+			s += "\n[" + strconv.Itoa(i+1) + "] " + u
+		}
+	}
+	return s
+}
+
+// TextToHTML converts the plain text s to an HTML fragment.
+// Special characters are escaped,
+// runs of lines separated by blank lines become paragraphs,
+// single line breaks become br elements,
+// and http and https URLs become links.
+func TextToHTML(s string) string {
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+	var b strings.Builder
+	var para []string
+	emit := func() {
+		if len(para) == 0 {
+			return
+		}
+		if b.Len() > 0 {
+			b.WriteByte('\n')
+		}
+		b.WriteString("<p>")
+		for i, line := range para {
+			if i > 0 {
+				b.WriteString("<br>\n")
+			}
+			b.WriteString(autolink(line))
+		}
+		b.WriteString("</p>")
+		para = para[:0]
+	}
+	for _, line := range strings.Split(s, "\n") {
+		line = strings.TrimRightFunc(line, unicode.IsSpace)
+		if line == "" {
+			emit()
+			continue
+		}
+		para = append(para, line)
+	}
+	emit()
+	return b.String()
+}
+
+// autolink escapes s for use in HTML
+// and wraps each http or https URL in a link.
+func autolink(s string) string {
+	var b strings.Builder
+	for {
+		i := urlStart(s)
+		if i < 0 {
+			b.WriteString(html.EscapeString(s))
+			return b.String()
+		}
+		b.WriteString(html.EscapeString(s[:i]))
+		s = s[i:]
+		n := urlLen(s)
+		u := html.EscapeString(s[:n])
+		b.WriteString(`<a href="` + u + `">` + u + `</a>`)
+		s = s[n:]
+	}
+}
+
+// urlStart returns the index of the first
+// http or https URL in s, or -1.
+func urlStart(s string) int {
+	off := 0
+	for {
+		i := strings.Index(s[off:], "http")
+		if i < 0 {
+			return -1
+		}
+		i += off
+		rest := s[i:]
+		if (strings.HasPrefix(rest, "http://") || strings.HasPrefix(rest, "https://")) &&
+			(i == 0 || !isWordByte(s[i-1])) {
+			if n := urlLen(rest); n > strings.Index(rest, "//")+2 {
+				return i
+			}
+		}
+		off = i + 4
+	}
+}
+
+// urlLen returns the length of the URL at the start of s.
+// Trailing punctuation is not considered part of the URL,
+// except for a closing parenthesis that has a match in the URL.
+func urlLen(s string) int {
+	n := strings.IndexFunc(s, func(r rune) bool {
+		return unicode.IsSpace(r) || r == '<' || r == '>' || r == '"'
+	})
+	if n < 0 {
+		n = len(s)
+	}
+	for n > 0 {
+		c := s[n-1]
+		if c == ')' && strings.Count(s[:n], "(") >= strings.Count(s[:n], ")") {
+			break
+		}
+		if !strings.ContainsRune(".,;:!?'*)]", rune(c)) {
+			break
+		}
+		n--
+	}
+	return n
+}
+
+func isWordByte(c byte) bool {
+	return isTagNameByte(c) || c == '_'
+}
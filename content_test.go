@@ -0,0 +1,120 @@
+package jsonfeed
+
+import "testing"
+
+func TestHTMLToText(t *testing.T) {
+	cases := []struct{ html, text string }{
+		{``, ``},
+		{`plain`, `plain`},
+		{`<p>one</p><p>two</p>`, "one\n\ntwo"},
+		{"<p>  lots \n of\tspace  </p>", `lots of space`},
+		{`a<br>b<br/>c`, "a\nb\nc"},
+		{`Tom &amp; Jerry &lt;3`, `Tom & Jerry <3`},
+		{`1 < 2`, `1 < 2`},
+		{`<ul><li>a</li><li>b</li></ul>`, "- a\n- b"},
+		{`<ol><li>a<li>b</ol>`, "1. a\n2. b"},
+		{
+			`<ul><li>a<ol><li>x</li><li>y</li></ol></li><li>b</li></ul><p>after</p>`,
+			"- a\n  1. x\n  2. y\n- b\n\nafter",
+		},
+		{`<li>stray</li>`, `stray`},
+		{`</ul>text`, `text`},
+		{
+			`<p>See <a href="https://a.example/">this</a> and <a href='https://b.example/'>that</a>.</p>`,
+			"See this[1] and that[2].\n\n[1] https://a.example/\n[2] https://b.example/",
+		},
+		{
+			`<a href=https://a.example/>x</a> <a href="https://a.example/">y</a>`,
+			"x[1] y[1]\n\n[1] https://a.example/",
+		},
+		{`<a href="https://a.example/">https://a.example/</a>`, `https://a.example/`},
+		{`<a href="mailto:me@example.org">me@example.org</a>`, `me@example.org`},
+		{`<a href="#top">top</a> <a name="x">x</a>`, `top x`},
+		{`<a href="javascript:void(0)">js</a>`, `js`},
+		{`<a href="https://a.example/">a<a href="https://b.example/">b</a>`, "a[1]b[2]\n\n[1] https://a.example/\n[2] https://b.example/"},
+		{`<a href="https://a.example/">unterminated`, "unterminated[1]\n\n[1] https://a.example/"},
+		{`<a href="https://a.example/">a </a>b`, "a[1] b\n\n[1] https://a.example/"},
+		{
+			`<p>First para.</p><p><a href="https://x.example/"><img src="i.png"></a></p>`,
+			"First para.\n\n[1]\n\n[1] https://x.example/",
+		},
+		{`<a title="say &quot;hi&quot;" data-x = y href = "/a?b=1&amp;c=2" disabled>q</a>`, "q[1]\n\n[1] /a?b=1&c=2"},
+		{"<pre>  keep\n    this</pre><p>x</p>", "  keep\n    this\n\nx"},
+		{`a<script>alert("<p>")</script>b<style>p{}</STYLE>c`, `abc`},
+		{`a<script>never closed`, `a`},
+		{`a<script>never closed</script`, `a`},
+		{`a</script>b`, `ab`},
+		{`<!DOCTYPE html><!-- note -->x<?pi?>`, `x`},
+		{`x<!-- unterminated`, `x`},
+		{`x<!unterminated`, `x`},
+		{`<table><tr><td>a</td><td>b</td></tr><tr><td>c</td></tr></table>`, "a b\nc"},
+		{`x<b title="unterminated`, `x`},
+		{`x<a href="unterminated`, "x[1]\n\n[1] unterminated"},
+		{`x<a href='unterminated>`, "x[1]\n\n[1] unterminated>"},
+		{`x<a href`, `x`},
+		{`x<b`, `x`},
+		{`<b "q>" >y`, `y`},
+		{`x<b "unterminated`, `x`},
+	}
+
+	for _, test := range cases {
+		got := HTMLToText(test.html)
+		if got != test.text {
+			t.Errorf("HTMLToText(%q) = %q, want %q", test.html, got, test.text)
+		}
+	}
+}
+
+func TestTextToHTML(t *testing.T) {
+	cases := []struct{ text, html string }{
+		{``, ``},
+		{`a < b & c`, `<p>a &lt; b &amp; c</p>`},
+		{"one\ntwo\n\n\nthree  \r\n", "<p>one<br>\ntwo</p>\n<p>three</p>"},
+		{
+			`see https://example.org/a.`,
+			`<p>see <a href="https://example.org/a">https://example.org/a</a>.</p>`,
+		},
+		{
+			`(http://example.org/wiki/Go_(language)) ok`,
+			`<p>(<a href="http://example.org/wiki/Go_(language)">http://example.org/wiki/Go_(language)</a>) ok</p>`,
+		},
+		{
+			`http://x.example/?a=1&b="2"`,
+			`<p><a href="http://x.example/?a=1&amp;b=">http://x.example/?a=1&amp;b=</a>&#34;2&#34;</p>`,
+		},
+		{`xhttp://nope.example http:// https`, `<p>xhttp://nope.example http:// https</p>`},
+	}
+
+	for _, test := range cases {
+		got := TextToHTML(test.text)
+		if got != test.html {
+			t.Errorf("TextToHTML(%q) = %q, want %q", test.text, got, test.html)
+		}
+	}
+}
+
+func TestFillContent(t *testing.T) {
+	cases := []struct{ in, want Item }{
+		{
+			Item{ContentText: "a"},
+			Item{ContentText: "a", ContentHTML: "<p>a</p>"},
+		},
+		{
+			Item{ContentHTML: "<p>a</p>"},
+			Item{ContentText: "a", ContentHTML: "<p>a</p>"},
+		},
+		{
+			Item{ContentText: "a", ContentHTML: "b"},
+			Item{ContentText: "a", ContentHTML: "b"},
+		},
+		{Item{}, Item{}},
+	}
+
+	for _, test := range cases {
+		got := test.in
+		got.FillContent()
+		if got.ContentText != test.want.ContentText || got.ContentHTML != test.want.ContentHTML {
+			t.Errorf("(%v).FillContent() => %v, want %v", test.in, got, test.want)
+		}
+	}
+}
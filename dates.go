@@ -0,0 +1,167 @@
+package jsonfeed
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// A FieldError describes a problem with one value in a JSON feed.
+// UnmarshalOptions uses it both for errors
+// and for warnings about problems it tolerated.
+type FieldError struct {
+	// Path locates the value in the feed,
+	// such as "items[3].date_published".
+	Path string
+	Err  error
+}
+
+func (e *FieldError) Error() string {
+	return "jsonfeed: " + e.Path + ": " + e.Err.Error()
+}
+
+func (e *FieldError) Unwrap() error {
+	return e.Err
+}
+
+// dateLayouts lists the date formats accepted
+// by UnmarshalOptions.LenientDates, besides RFC 3339.
+// Dates with no time zone are taken to be in UTC.
+// Time zone names must be UTC or one of those in RFC 822,
+// unless the local time zone gives them an offset.
+// Parsing accepts fractional seconds after the seconds
+// even though these layouts don't show them.
+var dateLayouts = []string{
+	"2006-01-02T15:04:05Z0700",
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05Z07:00",
+	"2006-01-02 15:04:05Z0700",
+	"2006-01-02 15:04:05 Z07:00",
+	"2006-01-02 15:04:05 Z0700",
+	"2006-01-02 15:04:05",
+	"2006-01-02T15:04Z07:00",
+	"2006-01-02T15:04",
+	"2006-01-02 15:04",
+	"2006-01-02",
+	time.RFC1123Z,
+	time.RFC1123,
+	"Mon, 2 Jan 2006 15:04:05 -0700",
+	"Mon, 2 Jan 2006 15:04:05 MST",
+	"2 Jan 2006 15:04:05 -0700",
+	time.RFC822Z,
+	time.RFC822,
+	time.RFC850,
+	time.UnixDate,
+	time.ANSIC,
+}
+
+// rfc822Zones gives the offsets of the time zone names in RFC 822.
+// Without them, time.Parse would give these names a zero offset,
+// unless they name the local time zone.
+var rfc822Zones = map[string]int{
+	"UT":  0,
+	"GMT": 0,
+	"EST": -5 * 60 * 60,
+	"EDT": -4 * 60 * 60,
+	"CST": -6 * 60 * 60,
+	"CDT": -5 * 60 * 60,
+	"MST": -7 * 60 * 60,
+	"MDT": -6 * 60 * 60,
+	"PST": -8 * 60 * 60,
+	"PDT": -7 * 60 * 60,
+}
+
+// parseLenientDate parses the JSON value b as a date
+// in any of the formats in dateLayouts,
+// or as a Unix time in seconds or milliseconds,
+// given as a number or a string.
+func parseLenientDate(b json.RawMessage) (time.Time, error) {
+	s := string(b)
+	if strings.HasPrefix(s, `"`) {
+		json.Unmarshal(b, &s) // already known to be valid
+		s = strings.TrimSpace(s)
+		if v, ok := strings.CutSuffix(s, " UT"); ok {
+			s = v + " UTC" // too short for time.Parse
+		}
+		for _, layout := range append([]string{time.RFC3339}, dateLayouts...) {
+			t, err := time.Parse(layout, s)
+			if err != nil {
+				continue
+			}
+			if !strings.Contains(layout, "MST") {
+				return t, nil
+			}
+			name, off := t.Zone()
+			if rfcOff, ok := rfc822Zones[name]; ok {
+				y, m, d := t.Date()
+				return time.Date(y, m, d, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.FixedZone(name, rfcOff)), nil
+			}
+			if off == 0 && name != "UTC" {
+				return time.Time{}, errors.New("unknown time zone " + name + " in date " + string(b))
+			}
+			return t, nil
+		}
+	}
+	n, err := strconv.ParseFloat(s, 64)
+	if err != nil || math.IsInf(n, 0) || math.IsNaN(n) {
+		return time.Time{}, errors.New("unrecognized date " + string(b))
+	}
+	if math.Abs(n) >= 1e11 { // too far off for seconds; must be milliseconds
+		return time.UnixMilli(int64(n)).UTC(), nil
+	}
+	sec, frac := math.Modf(n)
+	return time.Unix(int64(sec), int64(frac*1e9)).UTC(), nil
+}
+
+// checkDates checks the item dates in the JSON feed b,
+// returning a *FieldError for the first one that is not RFC 3339.
+// If o.LenientDates is set, it instead returns a copy of b
+// with any dates it can parse rewritten in RFC 3339,
+// reporting each to o.Warn.
+// If b is not shaped like a feed,
+// checkDates returns it unchanged
+// and leaves json.Unmarshal to report the problem.
+func (o UnmarshalOptions) checkDates(b []byte) ([]byte, error) {
+	var feed map[string]json.RawMessage
+	var items []map[string]json.RawMessage
+	if json.Unmarshal(b, &feed) != nil || json.Unmarshal(feed["items"], &items) != nil {
+		return b, nil
+	}
+	changed := false
+	for i, t := range items {
+		for _, k := range []string{"date_published", "date_modified"} {
+			v, ok := t[k]
+			if !ok || string(v) == "null" {
+				continue
+			}
+			var d time.Time
+			err := json.Unmarshal(v, &d)
+			if err == nil {
+				continue
+			}
+			path := "items[" + strconv.Itoa(i) + "]." + k
+			if !o.LenientDates {
+				return nil, &FieldError{Path: path, Err: err}
+			}
+			d, err = parseLenientDate(v)
+			if err == nil {
+				t[k], err = d.MarshalJSON()
+			}
+			if err != nil {
+				return nil, &FieldError{Path: path, Err: err}
+			}
+			if o.Warn != nil {
+				o.Warn(&FieldError{Path: path, Err: errors.New("non-standard date " + string(v))})
+			}
+			changed = true
+		}
+	}
+	if !changed {
+		return b, nil
+	}
+	feed["items"], _ = json.Marshal(items) // raw values from b always encode
+	return json.Marshal(feed)
+}
@@ -0,0 +1,132 @@
+package jsonfeed
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseLenientDate(t *testing.T) {
+	utc := time.Date(2017, 5, 17, 10, 2, 3, 0, time.UTC)
+	pdt := time.FixedZone("", -7*60*60)
+	est := time.FixedZone("", -5*60*60)
+	cases := []struct {
+		in   string
+		want time.Time
+	}{
+		{`"2017-05-17T10:02:03Z"`, utc},
+		{`" 2017-05-17T10:02:03 "`, utc},
+		{`"2017-05-17T10:02:03-0700"`, time.Date(2017, 5, 17, 10, 2, 3, 0, pdt)},
+		{`"2017-05-17 10:02:03-07:00"`, time.Date(2017, 5, 17, 10, 2, 3, 0, pdt)},
+		{`"2017-05-17 10:02:03 +0000"`, utc},
+		{`"2017-05-17 10:02:03.5"`, utc.Add(500 * time.Millisecond)},
+		{`"2017-05-17T10:02"`, utc.Add(-3 * time.Second)},
+		{`"2017-05-17"`, time.Date(2017, 5, 17, 0, 0, 0, 0, time.UTC)},
+		{`"Wed, 17 May 2017 10:02:03 -0700"`, time.Date(2017, 5, 17, 10, 2, 3, 0, pdt)},
+		{`"Wed, 17 May 2017 10:02:03 GMT"`, utc},
+		{`"Wed, 17 May 2017 10:02:03 UT"`, utc},
+		{`"Wed, 17 May 2017 10:02:03 UTC"`, utc},
+		{`"Wed, 17 May 2017 03:02:03 PDT"`, utc},
+		{`"Wed, 17 May 2017 10:02:03 EST"`, time.Date(2017, 5, 17, 10, 2, 3, 0, est)},
+		{`"Wed May 17 05:02:03 EST 2017"`, time.Date(2017, 5, 17, 5, 2, 3, 0, est)},
+		{`"17 May 17 10:02 -0700"`, time.Date(2017, 5, 17, 10, 2, 0, 0, pdt)},
+		{`1495015323`, utc},
+		{`"1495015323"`, utc},
+		{`1495015323.25`, utc.Add(250 * time.Millisecond)},
+		{`1495015323000`, utc},
+	}
+	for _, test := range cases {
+		got, err := parseLenientDate(json.RawMessage(test.in))
+		if err != nil {
+			t.Errorf("parseLenientDate(%s) = %v, want nil", test.in, err)
+			continue
+		}
+		if !got.Equal(test.want) {
+			t.Errorf("parseLenientDate(%s) = %v, want %v", test.in, got, test.want)
+		}
+	}
+
+	for _, in := range []string{`"yesterday"`, `true`, `"NaN"`, `1e999`, `"Wed, 17 May 2017 10:02:03 XYZ"`} {
+		if got, err := parseLenientDate(json.RawMessage(in)); err == nil {
+			t.Errorf("parseLenientDate(%s) = %v, want error", in, got)
+		}
+	}
+}
+
+func TestUnmarshalOptionsDates(t *testing.T) {
+	b := []byte(`{
+		"version": "https://jsonfeed.org/version/1",
+		"title": "title",
+		"items": [
+			{"id": "a", "content_text": "a", "date_published": "2017-05-17T10:02:03Z"},
+			{"id": "b", "content_text": "b", "date_published": null, "date_modified": "2017-05-17 10:02:03", "_x": 1},
+			{"id": "c", "content_text": "c", "date_published": 1495015323}
+		]
+	}`)
+
+	var f Feed
+	err := UnmarshalOptions{}.Unmarshal(b, &f)
+	var fe *FieldError
+	if !errors.As(err, &fe) || fe.Path != "items[1].date_modified" {
+		t.Fatalf("Unmarshal() = %v, want error at items[1].date_modified", err)
+	}
+	if fe.Unwrap() == nil || fe.Error() == "" {
+		t.Errorf("FieldError = %#v, want message and cause", fe)
+	}
+
+	var warnings []string
+	o := UnmarshalOptions{
+		LenientDates: true,
+		Warn:         func(w *FieldError) { warnings = append(warnings, w.Path) },
+	}
+	if err := o.Unmarshal(b, &f); err != nil {
+		t.Fatalf("Unmarshal(LenientDates) = %v, want nil", err)
+	}
+	want := []string{"items[1].date_modified", "items[2].date_published"}
+	if !reflect.DeepEqual(warnings, want) {
+		t.Errorf("warnings = %q, want %q", warnings, want)
+	}
+	d := time.Date(2017, 5, 17, 10, 2, 3, 0, time.UTC)
+	for _, got := range []time.Time{f.Items[0].DatePublished, f.Items[1].DateModified, f.Items[2].DatePublished} {
+		if !got.Equal(d) {
+			t.Errorf("date = %v, want %v", got, d)
+		}
+	}
+	if string(f.Items[1].Extensions["_x"]) != "1" {
+		t.Errorf("extensions = %q, want _x", f.Items[1].Extensions)
+	}
+
+	o.Warn = nil
+	if err := o.Unmarshal(b, &f); err != nil {
+		t.Errorf("Unmarshal(LenientDates) with no Warn = %v, want nil", err)
+	}
+}
+
+func TestUnmarshalOptionsDatesBad(t *testing.T) {
+	cases := []struct {
+		in   string
+		path string
+	}{
+		{`{"items":[{"date_published":"soon"}]}`, "items[0].date_published"},
+		{`{"items":[{},{"date_modified":99999999999999999}]}`, "items[1].date_modified"},
+	}
+	for _, test := range cases {
+		var f Feed
+		err := UnmarshalOptions{LenientDates: true}.Unmarshal([]byte(test.in), &f)
+		var fe *FieldError
+		if !errors.As(err, &fe) || fe.Path != test.path {
+			t.Errorf("Unmarshal(%s) = %v, want error at %s", test.in, err, test.path)
+		}
+	}
+
+	// Malformed feeds are left for json.Unmarshal to report.
+	for _, in := range []string{`[]`, `{"items":{}}`, `{"items":[1]}`} {
+		var f Feed
+		err := UnmarshalOptions{LenientDates: true}.Unmarshal([]byte(in), &f)
+		if _, ok := err.(*FieldError); ok || err == nil {
+			t.Errorf("Unmarshal(%s) = %v, want json error", in, err)
+		}
+	}
+}
@@ -0,0 +1,168 @@
+package jsonfeed
+
+import (
+	"bytes"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+// A FeedDiff describes the differences
+// between two versions of a feed.
+type FeedDiff struct {
+	// Meta lists changes to feed-level fields,
+	// everything but the items.
+	Meta []Change
+
+	// Added lists items in the new version
+	// with IDs not in the old version,
+	// in the order they appear in the new version.
+	Added []Item
+
+	// Removed lists items in the old version
+	// with IDs not in the new version,
+	// in the order they appear in the old version.
+	Removed []Item
+
+	// Modified lists items present in both versions
+	// whose fields differ, other than in their dates alone,
+	// in the order they appear in the new version.
+	Modified []ItemDiff
+}
+
+// An ItemDiff describes the differences
+// between two versions of an item.
+type ItemDiff struct {
+	ID      string
+	Changes []Change
+}
+
+// A Change records a field whose value differs.
+type Change struct {
+	// Field is the JSON name of the field,
+	// such as "title" or "date_modified",
+	// or the name of an extension.
+	Field string
+
+	// Old and New hold the field's values,
+	// with the same type as the corresponding Go field,
+	// or json.RawMessage for an extension.
+	// An extension missing from one version is nil.
+	Old, New interface{}
+}
+
+// Empty reports whether d records no differences.
+func (d *FeedDiff) Empty() bool {
+	return len(d.Meta) == 0 && len(d.Added) == 0 &&
+		len(d.Removed) == 0 && len(d.Modified) == 0
+}
+
+// Diff compares two versions of a feed,
+// matching items by ID.
+// Dates are compared with time.Time.Equal,
+// so a change of time zone alone is not a change.
+// An item whose dates are its only changed fields
+// is not modified,
+// since unmarshaling gives an item with no dates the current time,
+// and two fetches of an unchanged feed would otherwise differ.
+func Diff(old, new *Feed) *FeedDiff {
+	d := &FeedDiff{Meta: diffFields(old, new, "items")}
+	oldItems := make(map[string]*Item, len(old.Items))
+	for i := range old.Items {
+		oldItems[old.Items[i].ID] = &old.Items[i]
+	}
+	newIDs := make(map[string]bool, len(new.Items))
+	for i := range new.Items {
+		t := &new.Items[i]
+		newIDs[t.ID] = true
+		o, ok := oldItems[t.ID]
+		if !ok {
+			d.Added = append(d.Added, *t)
+			continue
+		}
+		if c := diffItem(o, t); !onlyDates(c) {
+			d.Modified = append(d.Modified, ItemDiff{ID: t.ID, Changes: c})
+		}
+	}
+	for _, t := range old.Items {
+		if !newIDs[t.ID] {
+			d.Removed = append(d.Removed, t)
+		}
+	}
+	return d
+}
+
+// diffItem returns the changes from a to b,
+// which have the same ID.
+func diffItem(a, b *Item) []Change {
+	changes := diffFields(a, b, "id")
+	var keys []string
+	for k := range a.Extensions {
+		keys = append(keys, k)
+	}
+	for k := range b.Extensions {
+		if _, ok := a.Extensions[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		av, aok := a.Extensions[k]
+		bv, bok := b.Extensions[k]
+		if aok == bok && bytes.Equal(av, bv) {
+			continue
+		}
+		c := Change{Field: k}
+		if aok {
+			c.Old = av
+		}
+		if bok {
+			c.New = bv
+		}
+		changes = append(changes, c)
+	}
+	return changes
+}
+
+// onlyDates reports whether changes
+// are all to date fields, or empty.
+func onlyDates(changes []Change) bool {
+	for _, c := range changes {
+		if c.Field != "date_published" && c.Field != "date_modified" {
+			return false
+		}
+	}
+	return true
+}
+
+// diffFields compares the JSON-encoded fields
+// of a and b, which must be pointers to the same struct type,
+// except for the field named skip.
+func diffFields(a, b interface{}, skip string) []Change {
+	av, bv := reflect.ValueOf(a).Elem(), reflect.ValueOf(b).Elem()
+	var changes []Change
+	for i := 0; i < av.NumField(); i++ {
+		name := strings.Split(av.Type().Field(i).Tag.Get("json"), ",")[0]
+		if name == "" || name == "-" || name == skip {
+			continue
+		}
+		x, y := av.Field(i).Interface(), bv.Field(i).Interface()
+		if !equalField(x, y) {
+			changes = append(changes, Change{Field: name, Old: x, New: y})
+		}
+	}
+	return changes
+}
+
+// equalField reports whether x and y, of the same type,
+// are equal field values. Nil and empty slices are equal.
+func equalField(x, y interface{}) bool {
+	if t, ok := x.(time.Time); ok {
+		return t.Equal(y.(time.Time))
+	}
+	if v := reflect.ValueOf(x); v.Kind() == reflect.Slice && v.Len() == 0 {
+		return reflect.ValueOf(y).Len() == 0
+	}
+	return reflect.DeepEqual(x, y)
+}
@@ -0,0 +1,104 @@
+package jsonfeed
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestDiff(t *testing.T) {
+	d1 := time.Date(2017, 5, 17, 0, 0, 0, 0, time.UTC)
+	old := &Feed{
+		Title: "old",
+		Items: []Item{
+			{ID: "same", ContentText: "x", DatePublished: d1, Tags: []string{}},
+			{ID: "gone", ContentText: "x"},
+			{ID: "changed", ContentText: "x", Tags: []string{"a"}, Extensions: map[string]json.RawMessage{
+				"_kept": json.RawMessage(`1`),
+				"_gone": json.RawMessage(`1`),
+				"_diff": json.RawMessage(`1`),
+			}},
+		},
+	}
+	new := &Feed{
+		Title:   "new",
+		Expired: true,
+		Items: []Item{
+			{ID: "added", ContentText: "x"},
+			{ID: "changed", ContentText: "y", Tags: []string{"a", "b"}, Extensions: map[string]json.RawMessage{
+				"_kept":  json.RawMessage(`1`),
+				"_diff":  json.RawMessage(`2`),
+				"_added": json.RawMessage(`1`),
+			}},
+			{ID: "same", ContentText: "x", DatePublished: d1.In(time.FixedZone("", 3600))},
+		},
+	}
+	got := Diff(old, new)
+	want := &FeedDiff{
+		Meta: []Change{
+			{Field: "title", Old: "old", New: "new"},
+			{Field: "expired", Old: false, New: true},
+		},
+		Added:   []Item{new.Items[0]},
+		Removed: []Item{old.Items[1]},
+		Modified: []ItemDiff{{
+			ID: "changed",
+			Changes: []Change{
+				{Field: "content_text", Old: "x", New: "y"},
+				{Field: "tags", Old: []string{"a"}, New: []string{"a", "b"}},
+				{Field: "_added", New: json.RawMessage(`1`)},
+				{Field: "_diff", Old: json.RawMessage(`1`), New: json.RawMessage(`2`)},
+				{Field: "_gone", Old: json.RawMessage(`1`)},
+			},
+		}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Diff() = %+v, want %+v", got, want)
+	}
+	if got.Empty() {
+		t.Errorf("Diff().Empty() = true, want false")
+	}
+}
+
+func TestDiffEmpty(t *testing.T) {
+	f := &Feed{Title: "t", Items: []Item{{ID: "1", ContentText: "x"}}}
+	d := Diff(f, f)
+	if !d.Empty() {
+		t.Errorf("Diff(f, f) = %+v, want empty", d)
+	}
+}
+
+func TestDiffDates(t *testing.T) {
+	// Decoding an unchanged feed with no item dates twice
+	// gives items different dates.
+	in := []byte(`{"version": "https://jsonfeed.org/version/1", "title": "t", "items": [{"id": "1", "content_text": "x"}]}`)
+	var a, b Feed
+	if err := json.Unmarshal(in, &a); err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(in, &b); err != nil {
+		t.Fatal(err)
+	}
+	b.Items[0].DatePublished = a.Items[0].DatePublished.Add(time.Second)
+	b.Items[0].DateModified = a.Items[0].DateModified.Add(time.Second)
+	if d := Diff(&a, &b); !d.Empty() {
+		t.Errorf("Diff(dates only) = %+v, want empty", d)
+	}
+	p, err := MakePatch(&a, &b)
+	if err != nil || len(p.Ops) != 0 {
+		t.Errorf("MakePatch(dates only) = %+v, %v, want no ops", p, err)
+	}
+
+	// Dates are reported along with other changes.
+	b.Items[0].ContentText = "y"
+	want := []Change{
+		{Field: "content_text", Old: "x", New: "y"},
+		{Field: "date_published", Old: a.Items[0].DatePublished, New: b.Items[0].DatePublished},
+		{Field: "date_modified", Old: a.Items[0].DateModified, New: b.Items[0].DateModified},
+	}
+	d := Diff(&a, &b)
+	if len(d.Modified) != 1 || !reflect.DeepEqual(d.Modified[0].Changes, want) {
+		t.Errorf("Diff() = %+v, want changes %+v", d.Modified, want)
+	}
+}
@@ -1 +1,3 @@
-module "github.com/kr/jsonfeed"
+module github.com/kr/jsonfeed
+
+go 1.25
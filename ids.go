@@ -0,0 +1,154 @@
+package jsonfeed
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// The functions in this file make item IDs
+// that depend only on the item,
+// so the same item gets the same ID every time a feed is built,
+// as the spec requires.
+// Pass them to Builder.GenerateIDs.
+
+// TagURI returns an RFC 4151 tag URI
+// naming specific, minted by authority on the given date,
+// such as "tag:example.org,2017-05-17:hello-world".
+// Authority should be a domain name or email address
+// owned by the publisher on that date.
+// Specific is escaped as needed.
+func TagURI(authority string, date time.Time, specific string) string {
+	return "tag:" + authority + "," + date.UTC().Format("2006-01-02") + ":" + url.PathEscape(specific)
+}
+
+// TagIDs returns a function that makes a tag URI for an item,
+// as by TagURI, with the given authority,
+// the item's DatePublished,
+// and a slug from the last element of the item's URL,
+// without any extension,
+// or from its title if that gives none.
+// The function returns an error if the item
+// has no DatePublished or no slug.
+func TagIDs(authority string) func(t *Item) (string, error) {
+	return func(t *Item) (string, error) {
+		if t.DatePublished.IsZero() {
+			return "", errors.New("no date_published for tag id")
+		}
+		var s string
+		if u, err := url.Parse(t.URL); err == nil {
+			base := path.Base(u.Path)
+			s = slug(strings.TrimSuffix(base, path.Ext(base)))
+		}
+		if s == "" {
+			s = slug(t.Title)
+		}
+		if s == "" {
+			return "", errors.New("no url or title for tag id")
+		}
+		return TagURI(authority, t.DatePublished, s), nil
+	}
+}
+
+// slug returns s in lower case,
+// with each run of characters other than letters and digits
+// replaced by a single hyphen,
+// and no leading or trailing hyphen.
+func slug(s string) string {
+	var b strings.Builder
+	hyphen := false
+	for _, r := range strings.ToLower(s) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if hyphen && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			b.WriteRune(r)
+			hyphen = false
+		} else {
+			hyphen = true
+		}
+	}
+	return b.String()
+}
+
+// HashIDs returns a function that makes an ID for an item
+// from prefix and a SHA-256 hash of the item's
+// title, content, and publication date.
+// Items that are updated get a new ID this way,
+// so use it only for items that never change,
+// such as short posts with no natural ID.
+func HashIDs(prefix string) func(t *Item) (string, error) {
+	return func(t *Item) (string, error) {
+		date := ""
+		if !t.DatePublished.IsZero() {
+			date = t.DatePublished.UTC().Format(time.RFC3339Nano)
+		}
+		return prefix + hashStrings(t.Title, t.ContentHTML, t.ContentText, date), nil
+	}
+}
+
+// hashStrings returns a hex-encoded 128-bit hash of the list a,
+// so that different lists give different hashes.
+func hashStrings(a ...string) string {
+	h := sha256.New()
+	for _, s := range a {
+		h.Write(binary.BigEndian.AppendUint64(nil, uint64(len(s))))
+		h.Write([]byte(s))
+	}
+	return hex.EncodeToString(h.Sum(nil)[:16])
+}
+
+// URLIDs makes an ID for an item from its URL,
+// normalized by NormalizeURL.
+// It returns an error if the item has no URL.
+func URLIDs(t *Item) (string, error) {
+	if t.URL == "" {
+		return "", errors.New("no url for id")
+	}
+	return NormalizeURL(t.URL)
+}
+
+// NormalizeURL returns the absolute URL s in a normal form,
+// so that URLs that differ only in unimportant ways are the same.
+// It lowercases the scheme and host,
+// removes the default port for http and https,
+// removes the fragment and any "." and ".." path elements,
+// uses "/" for an empty path,
+// and sorts the query parameters.
+func NormalizeURL(s string) (string, error) {
+	u, err := url.Parse(s)
+	if err != nil {
+		return "", err
+	}
+	if !u.IsAbs() || u.Opaque != "" {
+		return "", errors.New("not an absolute hierarchical URL: " + s)
+	}
+	u.Scheme = strings.ToLower(u.Scheme)
+	u.Host = strings.ToLower(u.Host)
+	if p := u.Port(); p == "80" && u.Scheme == "http" || p == "443" && u.Scheme == "https" {
+		u.Host = strings.TrimSuffix(u.Host, ":"+p)
+	}
+	u.Fragment, u.RawFragment = "", ""
+	if u.Path == "" {
+		u.Path = "/"
+	} else {
+		p := path.Clean(u.Path)
+		if strings.HasSuffix(u.Path, "/") && p != "/" {
+			p += "/"
+		}
+		u.Path, u.RawPath = p, ""
+	}
+	if u.RawQuery != "" {
+		q := strings.Split(u.RawQuery, "&")
+		sort.Strings(q)
+		u.RawQuery = strings.Join(q, "&")
+	}
+	return u.String(), nil
+}
@@ -0,0 +1,86 @@
+package jsonfeed
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTagIDs(t *testing.T) {
+	d := time.Date(2017, 5, 17, 23, 0, 0, 0, time.FixedZone("", -7*60*60))
+	fn := TagIDs("example.org")
+	cases := []struct {
+		item Item
+		want string
+	}{
+		{Item{URL: "https://example.org/2017/Hello-World.html", DatePublished: d}, "tag:example.org,2017-05-18:hello-world"},
+		{Item{URL: "https://example.org/", Title: "Ça va? Oui!", DatePublished: d}, "tag:example.org,2017-05-18:%C3%A7a-va-oui"},
+		{Item{Title: "  Go 1.23  ", DatePublished: d}, "tag:example.org,2017-05-18:go-1-23"},
+		{Item{URL: "::", Title: "x", DatePublished: d}, "tag:example.org,2017-05-18:x"},
+	}
+	for _, test := range cases {
+		got, err := fn(&test.item)
+		if err != nil || got != test.want {
+			t.Errorf("TagIDs(%+v) = %q, %v, want %q", test.item, got, err, test.want)
+		}
+	}
+	for _, item := range []Item{{Title: "x"}, {URL: "https://example.org/", DatePublished: d}} {
+		if got, err := fn(&item); err == nil {
+			t.Errorf("TagIDs(%+v) = %q, want error", item, got)
+		}
+	}
+}
+
+func TestHashIDs(t *testing.T) {
+	fn := HashIDs("urn:x:")
+	d := time.Date(2017, 5, 17, 0, 0, 0, 0, time.UTC)
+	a := Item{ContentText: "hello", DatePublished: d}
+	b := Item{ContentText: "hello", DatePublished: d.In(time.FixedZone("", 3600)), Tags: []string{"t"}}
+	c := Item{ContentHTML: "hello", DatePublished: d}
+	e := Item{ContentText: "hello"}
+	ida, _ := fn(&a)
+	idb, _ := fn(&b)
+	idc, _ := fn(&c)
+	ide, _ := fn(&e)
+	if !strings.HasPrefix(ida, "urn:x:") || len(ida) != len("urn:x:")+32 {
+		t.Errorf("HashIDs() = %q, want urn:x: and 32 hex digits", ida)
+	}
+	if ida != idb {
+		t.Errorf("HashIDs() differs for equivalent items: %q, %q", ida, idb)
+	}
+	if ida == idc || ida == ide {
+		t.Errorf("HashIDs() is the same for different items: %q", ida)
+	}
+}
+
+func TestNormalizeURL(t *testing.T) {
+	cases := []struct{ in, want string }{
+		{"HTTP://Example.ORG", "http://example.org/"},
+		{"http://example.org:80/a/./b/../c", "http://example.org/a/c"},
+		{"https://example.org:443/a/b/#frag", "https://example.org/a/b/"},
+		{"https://example.org:8443/?b=2&a=1&a=0", "https://example.org:8443/?a=0&a=1&b=2"},
+		{"http://example.org:443/%7Ex/", "http://example.org:443/~x/"},
+		{"http://example.org/..", "http://example.org/"},
+	}
+	for _, test := range cases {
+		got, err := NormalizeURL(test.in)
+		if err != nil || got != test.want {
+			t.Errorf("NormalizeURL(%q) = %q, %v, want %q", test.in, got, err, test.want)
+		}
+	}
+	for _, in := range []string{"::", "/relative", "mailto:kr@example.org"} {
+		if got, err := NormalizeURL(in); err == nil {
+			t.Errorf("NormalizeURL(%q) = %q, want error", in, got)
+		}
+	}
+}
+
+func TestURLIDs(t *testing.T) {
+	got, err := URLIDs(&Item{URL: "HTTPS://example.org/a#x"})
+	if want := "https://example.org/a"; err != nil || got != want {
+		t.Errorf("URLIDs() = %q, %v, want %q", got, err, want)
+	}
+	if _, err := URLIDs(&Item{}); err == nil {
+		t.Errorf("URLIDs(no url) = nil, want error")
+	}
+}
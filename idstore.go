@@ -0,0 +1,146 @@
+package jsonfeed
+
+import (
+	"sort"
+	"time"
+)
+
+// An IDStore records every item ID a feed has published,
+// so that CheckIDs can enforce the spec's rule
+// that new items never use a previously-used ID.
+// See RecordIDs and CheckIDs.
+type IDStore interface {
+	// Load returns all records in the store, keyed by ID.
+	Load() (map[string]IDRecord, error)
+
+	// Store adds recs to the store,
+	// replacing any records with the same IDs.
+	Store(recs []IDRecord) error
+}
+
+// An IDRecord describes the item that used an ID.
+type IDRecord struct {
+	ID string `json:"id"`
+
+	// URL is the item's URL, normalized by NormalizeURL
+	// if possible, when it was first recorded.
+	URL string `json:"url,omitempty"`
+
+	// Hash is a hash of the item's title and content
+	// when it was last recorded.
+	Hash string `json:"hash"`
+
+	// Present says whether the item was in
+	// the feed most recently recorded.
+	Present bool `json:"present"`
+
+	// FirstSeen is when the ID was first recorded.
+	FirstSeen time.Time `json:"first_seen"`
+}
+
+// contentHash returns the hash of t stored in IDRecord.Hash.
+func contentHash(t *Item) string {
+	return hashStrings(t.Title, t.ContentHTML, t.ContentText)
+}
+
+// recordURL returns the URL of t as recorded in an IDRecord.
+func recordURL(t *Item) string {
+	if u, err := NormalizeURL(t.URL); err == nil {
+		return u
+	}
+	return t.URL
+}
+
+// An IDReuseError describes an item ID
+// previously used for a different item.
+type IDReuseError struct {
+	ID     string
+	OldURL string // URL of the item that used ID first
+	NewURL string // URL of the item now using ID
+}
+
+func (e *IDReuseError) Error() string {
+	return "jsonfeed: id " + e.ID + " reused: was " + e.OldURL + ", now " + e.NewURL
+}
+
+// An IDReappearError describes an item ID that was removed
+// from a feed and has come back with different content.
+// An item that comes back unchanged is not an error.
+type IDReappearError struct {
+	ID string
+}
+
+func (e *IDReappearError) Error() string {
+	return "jsonfeed: id " + e.ID + " removed and reused with different content"
+}
+
+// CheckIDs returns an error if f reuses an ID recorded in s.
+// That is, if an item has an ID recorded for an item
+// with a different URL, the error is an *IDReuseError,
+// though items with no URL, and IDs recorded with no URL,
+// are not checked for this,
+// and if an item has the ID of an item since removed from the feed,
+// but with different title or content,
+// the error is an *IDReappearError.
+// Items present in the last recorded feed may change freely.
+func CheckIDs(s IDStore, f *Feed) error {
+	recs, err := s.Load()
+	if err != nil {
+		return err
+	}
+	for i := range f.Items {
+		t := &f.Items[i]
+		r, ok := recs[t.ID]
+		if !ok {
+			continue
+		}
+		if u := recordURL(t); r.URL != "" && u != "" && u != r.URL {
+			return &IDReuseError{ID: t.ID, OldURL: r.URL, NewURL: u}
+		}
+		if !r.Present && r.Hash != contentHash(t) {
+			return &IDReappearError{ID: t.ID}
+		}
+	}
+	return nil
+}
+
+// RecordIDs records in s that f was published at time now.
+// It adds a record for each new ID,
+// updates the records of items that changed,
+// and marks as no longer present the items
+// that were removed since the last call.
+// Only records that changed are stored.
+func RecordIDs(s IDStore, f *Feed, now time.Time) error {
+	recs, err := s.Load()
+	if err != nil {
+		return err
+	}
+	var changed []IDRecord
+	seen := make(map[string]bool, len(f.Items))
+	for i := range f.Items {
+		t := &f.Items[i]
+		seen[t.ID] = true
+		r, ok := recs[t.ID]
+		if !ok {
+			r = IDRecord{ID: t.ID, URL: recordURL(t), FirstSeen: now}
+		}
+		h := contentHash(t)
+		if !ok || !r.Present || r.Hash != h {
+			r.Hash, r.Present = h, true
+			changed = append(changed, r)
+		}
+	}
+	var removed []IDRecord
+	for id, r := range recs {
+		if r.Present && !seen[id] {
+			r.Present = false
+			removed = append(removed, r)
+		}
+	}
+	sort.Slice(removed, func(i, j int) bool { return removed[i].ID < removed[j].ID })
+	changed = append(changed, removed...)
+	if len(changed) == 0 {
+		return nil
+	}
+	return s.Store(changed)
+}
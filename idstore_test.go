@@ -0,0 +1,157 @@
+package jsonfeed
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// memIDStore is an IDStore in memory.
+type memIDStore struct {
+	recs   map[string]IDRecord
+	stored [][]IDRecord
+	err    error
+}
+
+func (s *memIDStore) Load() (map[string]IDRecord, error) {
+	m := make(map[string]IDRecord)
+	for k, v := range s.recs {
+		m[k] = v
+	}
+	return m, s.err
+}
+
+func (s *memIDStore) Store(recs []IDRecord) error {
+	if s.recs == nil {
+		s.recs = make(map[string]IDRecord)
+	}
+	for _, r := range recs {
+		s.recs[r.ID] = r
+	}
+	s.stored = append(s.stored, recs)
+	return nil
+}
+
+func TestRecordCheckIDs(t *testing.T) {
+	d0 := time.Date(2017, 5, 17, 0, 0, 0, 0, time.UTC)
+	d1 := d0.Add(24 * time.Hour)
+	s := new(memIDStore)
+	f := &Feed{Items: []Item{
+		{ID: "a", URL: "https://example.org/a", ContentText: "a"},
+		{ID: "b", ContentText: "b"},
+	}}
+	if err := CheckIDs(s, f); err != nil {
+		t.Fatalf("CheckIDs(empty store) = %v, want nil", err)
+	}
+	if err := RecordIDs(s, f, d0); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]IDRecord{
+		"a": {ID: "a", URL: "https://example.org/a", Hash: contentHash(&f.Items[0]), Present: true, FirstSeen: d0},
+		"b": {ID: "b", Hash: contentHash(&f.Items[1]), Present: true, FirstSeen: d0},
+	}
+	if !reflect.DeepEqual(s.recs, want) {
+		t.Errorf("RecordIDs() stored %+v, want %+v", s.recs, want)
+	}
+
+	// Publishing the same feed again stores nothing.
+	RecordIDs(s, f, d1)
+	if len(s.stored) != 1 {
+		t.Errorf("RecordIDs(unchanged) stored %+v, want nothing", s.stored[1:])
+	}
+
+	// Items still present may change, but not their URL.
+	f.Items[0].ContentText = "a2"
+	if err := CheckIDs(s, f); err != nil {
+		t.Errorf("CheckIDs(changed item) = %v, want nil", err)
+	}
+	f.Items[0].URL = "https://example.org/other"
+	var re *IDReuseError
+	if err := CheckIDs(s, f); !errors.As(err, &re) || re.ID != "a" {
+		t.Errorf("CheckIDs(changed url) = %v, want IDReuseError", err)
+	}
+	f.Items[0].URL = "https://example.org/a"
+
+	// Remove b, then bring it back.
+	RecordIDs(s, &Feed{Items: f.Items[:1]}, d1)
+	if r := s.recs["b"]; r.Present || !r.FirstSeen.Equal(d0) {
+		t.Errorf("record for removed b = %+v, want not present", r)
+	}
+	if r := s.recs["a"]; r.Hash != contentHash(&f.Items[0]) {
+		t.Errorf("record for changed a = %+v, want new hash", r)
+	}
+	if err := CheckIDs(s, f); err != nil {
+		t.Errorf("CheckIDs(b unchanged) = %v, want nil", err)
+	}
+	f.Items[1].ContentText = "new b"
+	var ra *IDReappearError
+	if err := CheckIDs(s, f); !errors.As(err, &ra) || ra.ID != "b" {
+		t.Errorf("CheckIDs(b changed) = %v, want IDReappearError", err)
+	}
+	if ra.Error() != "jsonfeed: id b removed and reused with different content" {
+		t.Errorf("Error() = %q", ra.Error())
+	}
+	if err := RecordIDs(s, f, d1); err != nil || !s.recs["b"].Present {
+		t.Errorf("RecordIDs(b back) = %v, record %+v", err, s.recs["b"])
+	}
+
+	// Removed records are stored in order.
+	RecordIDs(s, &Feed{}, d1)
+	last := s.stored[len(s.stored)-1]
+	if len(last) != 2 || last[0].ID != "a" || last[1].ID != "b" {
+		t.Errorf("RecordIDs(empty feed) stored %+v, want a, b", last)
+	}
+
+	s.err = errors.New("load")
+	if err := CheckIDs(s, f); err != s.err {
+		t.Errorf("CheckIDs(load error) = %v, want %v", err, s.err)
+	}
+	if err := RecordIDs(s, f, d1); err != s.err {
+		t.Errorf("RecordIDs(load error) = %v, want %v", err, s.err)
+	}
+}
+
+func TestCheckIDsURL(t *testing.T) {
+	s := new(memIDStore)
+	RecordIDs(s, &Feed{Items: []Item{
+		{ID: "1", URL: "https://example.org/1"},
+		{ID: "2"},
+		{ID: "3", URL: "rel/3"},
+	}}, time.Now())
+	RecordIDs(s, &Feed{Items: []Item{{ID: "1", URL: "https://example.org/other"}}}, time.Now())
+	ok := &Feed{Items: []Item{
+		{ID: "1", URL: "https://EXAMPLE.org/1#top"},
+		{ID: "2", URL: "https://example.org/2"},
+		{ID: "3", URL: "rel/3"},
+		{ID: "4", URL: "https://example.org/4"},
+	}}
+	if err := CheckIDs(s, ok); err != nil {
+		t.Errorf("CheckIDs() = %v, want nil", err)
+	}
+	bad := &Feed{Items: []Item{{ID: "1", URL: "https://example.org/new"}}}
+	err := CheckIDs(s, bad)
+	var re *IDReuseError
+	if !errors.As(err, &re) || re.OldURL != "https://example.org/1" || re.NewURL != "https://example.org/new" {
+		t.Fatalf("CheckIDs() = %v, want IDReuseError", err)
+	}
+	if err.Error() != "jsonfeed: id 1 reused: was https://example.org/1, now https://example.org/new" {
+		t.Errorf("Error() = %q", err.Error())
+	}
+}
+
+func TestBuilderCheckIDs(t *testing.T) {
+	s := new(memIDStore)
+	RecordIDs(s, &Feed{Items: []Item{{ID: "a", ContentText: "a"}}}, time.Now())
+	RecordIDs(s, &Feed{}, time.Now())
+	b := new(Builder)
+	b.Title("t").CheckIDs(s).AddItem(Item{ID: "a", ContentText: "other"})
+	if _, err := b.Build(); err == nil {
+		t.Errorf("Build() = nil, want error for reused id")
+	}
+	b = new(Builder)
+	b.Title("t").CheckIDs(s).AddItem(Item{ID: "a", ContentText: "a"})
+	if _, err := b.Build(); err != nil {
+		t.Errorf("Build() = %v, want nil", err)
+	}
+}
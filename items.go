@@ -0,0 +1,106 @@
+package jsonfeed
+
+import (
+	"time"
+)
+
+// The methods in this file each return a new feed
+// with the same feed-level fields as f
+// and some or all of its items.
+// They do not modify f, and the new feed shares
+// no slices or maps with it.
+// Since each item is kept or dropped unchanged,
+// if f is valid, so is the result.
+
+// SortNewest returns a copy of f with its items sorted newest first
+// by DatePublished, or DateModified for items with no DatePublished.
+// Items with the same date are ordered by ID.
+func (f *Feed) SortNewest() *Feed {
+	c := f.Filter(nil)
+	sortNewest(c.Items)
+	return c
+}
+
+// Filter returns a copy of f with only the items for which keep
+// returns true, in their original order.
+// If keep is nil, all items are kept.
+func (f *Feed) Filter(keep func(t *Item) bool) *Feed {
+	c := *f
+	if f.Author != nil {
+		a := *f.Author
+		c.Author = &a
+	}
+	c.Hubs = append([]Hub(nil), f.Hubs...)
+	c.Items = make([]Item, 0, len(f.Items))
+	for i := range f.Items {
+		if keep == nil || keep(&f.Items[i]) {
+			c.Items = append(c.Items, copyItem(&f.Items[i]))
+		}
+	}
+	return &c
+}
+
+// FilterTag returns a copy of f with only the items
+// that have the given tag.
+func (f *Feed) FilterTag(tag string) *Feed {
+	return f.Filter(func(t *Item) bool {
+		for _, s := range t.Tags {
+			if s == tag {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// FilterAuthor returns a copy of f with only the items
+// whose author has the given name.
+// Items with no author of their own are attributed to
+// the feed's author, as the spec says.
+func (f *Feed) FilterAuthor(name string) *Feed {
+	return f.Filter(func(t *Item) bool {
+		a := t.EffectiveAuthor(f)
+		return a != nil && a.Name == name
+	})
+}
+
+// Between returns a copy of f with only the items dated
+// at or after start and before end,
+// using DatePublished, or DateModified for items with no DatePublished.
+// A zero start or end leaves that side of the range open.
+// Items with no date at all are kept only if both are zero.
+func (f *Feed) Between(start, end time.Time) *Feed {
+	return f.Filter(func(t *Item) bool {
+		d := itemDate(t)
+		if d.IsZero() {
+			return start.IsZero() && end.IsZero()
+		}
+		if !start.IsZero() && d.Before(start) {
+			return false
+		}
+		if !end.IsZero() && !d.Before(end) {
+			return false
+		}
+		return true
+	})
+}
+
+// MaxAge returns a copy of f without the items
+// dated more than d before the current time.
+// See Between for how items are dated.
+func (f *Feed) MaxAge(d time.Duration) *Feed {
+	return f.Between(time.Now().Add(-d), time.Time{})
+}
+
+// Latest returns a copy of f with only its n newest items,
+// sorted newest first as by SortNewest.
+func (f *Feed) Latest(n int) *Feed {
+	c := f.SortNewest()
+	if n < 0 {
+		n = 0
+	}
+	if len(c.Items) > n {
+		c.Items = c.Items[:n]
+	}
+	return c
+}
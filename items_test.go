@@ -0,0 +1,104 @@
+package jsonfeed
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func testItemsFeed() *Feed {
+	d := func(day int) time.Time { return time.Date(2017, 5, day, 0, 0, 0, 0, time.UTC) }
+	return &Feed{
+		Version: Version,
+		Title:   "t",
+		Author:  &Author{Name: "kr"},
+		Hubs:    []Hub{{Type: "WebSub", URL: "https://hub.example/"}},
+		Items: []Item{
+			{ID: "a", ContentText: "a", DatePublished: d(2), Tags: []string{"go"}},
+			{ID: "b", ContentText: "b", DateModified: d(4), Author: &Author{Name: "guest"}},
+			{ID: "c", ContentText: "c", DatePublished: d(3), DateModified: d(5), Tags: []string{"go", "x"}},
+			{ID: "d", ContentText: "d"},
+			{ID: "e", ContentText: "e", DatePublished: d(3)},
+		},
+	}
+}
+
+func itemIDs(f *Feed) []string {
+	ids := []string{}
+	for _, t := range f.Items {
+		ids = append(ids, t.ID)
+	}
+	return ids
+}
+
+func TestFeedItems(t *testing.T) {
+	cases := []struct {
+		name string
+		f    func(*Feed) *Feed
+		want []string
+	}{
+		{"SortNewest", (*Feed).SortNewest, []string{"b", "c", "e", "a", "d"}},
+		{"Filter(nil)", func(f *Feed) *Feed { return f.Filter(nil) }, []string{"a", "b", "c", "d", "e"}},
+		{"Filter", func(f *Feed) *Feed {
+			return f.Filter(func(t *Item) bool { return t.ID > "b" })
+		}, []string{"c", "d", "e"}},
+		{"FilterTag(go)", func(f *Feed) *Feed { return f.FilterTag("go") }, []string{"a", "c"}},
+		{"FilterTag(y)", func(f *Feed) *Feed { return f.FilterTag("y") }, []string{}},
+		{"FilterAuthor(kr)", func(f *Feed) *Feed { return f.FilterAuthor("kr") }, []string{"a", "c", "d", "e"}},
+		{"FilterAuthor(guest)", func(f *Feed) *Feed { return f.FilterAuthor("guest") }, []string{"b"}},
+		{"Between", func(f *Feed) *Feed {
+			return f.Between(time.Date(2017, 5, 3, 0, 0, 0, 0, time.UTC), time.Date(2017, 5, 4, 0, 0, 0, 0, time.UTC))
+		}, []string{"c", "e"}},
+		{"Between(start)", func(f *Feed) *Feed {
+			return f.Between(time.Date(2017, 5, 3, 0, 0, 0, 0, time.UTC), time.Time{})
+		}, []string{"b", "c", "e"}},
+		{"Between(end)", func(f *Feed) *Feed {
+			return f.Between(time.Time{}, time.Date(2017, 5, 4, 0, 0, 0, 0, time.UTC))
+		}, []string{"a", "c", "e"}},
+		{"Between(zero)", func(f *Feed) *Feed { return f.Between(time.Time{}, time.Time{}) }, []string{"a", "b", "c", "d", "e"}},
+		{"Latest(2)", func(f *Feed) *Feed { return f.Latest(2) }, []string{"b", "c"}},
+		{"Latest(9)", func(f *Feed) *Feed { return f.Latest(9) }, []string{"b", "c", "e", "a", "d"}},
+		{"Latest(-1)", func(f *Feed) *Feed { return f.Latest(-1) }, []string{}},
+		{"MaxAge", func(f *Feed) *Feed { return f.MaxAge(time.Hour) }, []string{}},
+	}
+	for _, test := range cases {
+		f := testItemsFeed()
+		got := test.f(f)
+		if ids := itemIDs(got); !reflect.DeepEqual(ids, test.want) {
+			t.Errorf("%s = %q, want %q", test.name, ids, test.want)
+		}
+		if err := validFeed(got); err != nil {
+			t.Errorf("%s: validFeed() = %v, want nil", test.name, err)
+		}
+		if !reflect.DeepEqual(f, testItemsFeed()) {
+			t.Errorf("%s modified its input", test.name)
+		}
+	}
+}
+
+func TestFeedItemsNoShare(t *testing.T) {
+	f := testItemsFeed()
+	c := f.Filter(nil)
+	c.Author.Name = "x"
+	c.Hubs[0].URL = "x"
+	c.Items[0].Tags[0] = "x"
+	if !reflect.DeepEqual(f, testItemsFeed()) {
+		t.Errorf("Filter(nil) shares data with its input")
+	}
+
+	f.Author = nil
+	if ids := itemIDs(f.FilterAuthor("kr")); len(ids) != 0 {
+		t.Errorf("FilterAuthor(kr) with no feed author = %q, want none", ids)
+	}
+}
+
+func TestMaxAge(t *testing.T) {
+	now := time.Now()
+	f := &Feed{Items: []Item{
+		{ID: "new", DatePublished: now.Add(-time.Minute)},
+		{ID: "old", DatePublished: now.Add(-2 * time.Hour)},
+	}}
+	if ids := itemIDs(f.MaxAge(time.Hour)); !reflect.DeepEqual(ids, []string{"new"}) {
+		t.Errorf("MaxAge(1h) = %q, want [new]", ids)
+	}
+}
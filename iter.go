@@ -0,0 +1,117 @@
+package jsonfeed
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"iter"
+	"strconv"
+)
+
+// All returns an iterator over the index and address of each item in f.
+// Changes made through the address modify f.
+func (f *Feed) All() iter.Seq2[int, *Item] {
+	return func(yield func(int, *Item) bool) {
+		for i := range f.Items {
+			if !yield(i, &f.Items[i]) {
+				return
+			}
+		}
+	}
+}
+
+// AllWithAuthors returns an iterator over the items in f
+// with the author of each, as given by Item.EffectiveAuthor.
+func (f *Feed) AllWithAuthors() iter.Seq2[*Item, *Author] {
+	return func(yield func(*Item, *Author) bool) {
+		for i := range f.Items {
+			t := &f.Items[i]
+			if !yield(t, t.EffectiveAuthor(f)) {
+				return
+			}
+		}
+	}
+}
+
+// AllAttachments returns an iterator over the index and address
+// of each attachment of t.
+func (t *Item) AllAttachments() iter.Seq2[int, *Attachment] {
+	return func(yield func(int, *Attachment) bool) {
+		for i := range t.Attachments {
+			if !yield(i, &t.Attachments[i]) {
+				return
+			}
+		}
+	}
+}
+
+// DecodeItems returns an iterator over the items
+// of the JSON feed read from r.
+// It decodes one item at a time,
+// so the whole feed need not fit in memory,
+// and it stops reading once the caller stops iterating.
+// The feed-level fields are skipped.
+//
+// Each item is checked as by UnmarshalJSON.
+// If reading, decoding, or checking fails,
+// the iterator yields the error and stops.
+func DecodeItems(r io.Reader) iter.Seq2[*Item, error] {
+	return func(yield func(*Item, error) bool) {
+		dec := json.NewDecoder(r)
+		ok, err := seekItems(dec)
+		if err != nil {
+			yield(nil, err)
+			return
+		}
+		for i := 0; ok && dec.More(); i++ {
+			t := new(Item)
+			if err := dec.Decode(t); err != nil {
+				yield(nil, err)
+				return
+			}
+			if err := validItem(t, "items["+strconv.Itoa(i)+"]"); err != nil {
+				yield(nil, err)
+				return
+			}
+			if !yield(t, nil) {
+				return
+			}
+		}
+	}
+}
+
+// seekItems reads from dec up to the first element
+// of the feed's items array.
+// It reports whether there is an items array.
+func seekItems(dec *json.Decoder) (bool, error) {
+	if tok, err := dec.Token(); err != nil {
+		return false, err
+	} else if tok != json.Delim('{') {
+		return false, errors.New("jsonfeed: feed is not a JSON object")
+	}
+	for dec.More() {
+		key, err := dec.Token()
+		if err != nil {
+			return false, err
+		}
+		if key != "items" {
+			var v json.RawMessage
+			if err := dec.Decode(&v); err != nil {
+				return false, err
+			}
+			continue
+		}
+		tok, err := dec.Token()
+		if err != nil {
+			return false, err
+		}
+		switch tok {
+		case json.Delim('['):
+			return true, nil
+		case nil:
+			return false, nil
+		}
+		return false, errors.New("jsonfeed: items is not a JSON array")
+	}
+	return false, nil
+}
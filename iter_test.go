@@ -0,0 +1,145 @@
+package jsonfeed
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestAll(t *testing.T) {
+	f := &Feed{Items: []Item{{ID: "a"}, {ID: "b"}, {ID: "c"}}}
+	var got []string
+	for i, it := range f.All() {
+		if it != &f.Items[i] {
+			t.Errorf("All() yielded %p at %d, want %p", it, i, &f.Items[i])
+		}
+		got = append(got, it.ID)
+		if i == 1 {
+			break
+		}
+	}
+	if want := []string{"a", "b"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("All() = %q, want %q", got, want)
+	}
+	for range f.All() {
+		// make sure a full iteration terminates
+	}
+}
+
+func TestAllWithAuthors(t *testing.T) {
+	kr, guest := &Author{Name: "kr"}, &Author{Name: "guest"}
+	f := &Feed{Author: kr, Items: []Item{{ID: "a"}, {ID: "b", Author: guest}, {ID: "c"}}}
+	var got []*Author
+	for _, a := range f.AllWithAuthors() {
+		got = append(got, a)
+	}
+	if want := []*Author{kr, guest, kr}; !reflect.DeepEqual(got, want) {
+		t.Errorf("AllWithAuthors() = %v, want %v", got, want)
+	}
+	for it, a := range f.AllWithAuthors() {
+		if it.ID != "a" || a != kr {
+			t.Errorf("AllWithAuthors() first = %v, %v, want a, %v", it.ID, a, kr)
+		}
+		break
+	}
+	f.Author = nil
+	for _, a := range f.AllWithAuthors() {
+		if a != nil {
+			t.Errorf("AllWithAuthors() with no feed author = %v, want nil", a)
+		}
+		break
+	}
+}
+
+func TestAllAttachments(t *testing.T) {
+	it := &Item{Attachments: []Attachment{{URL: "a"}, {URL: "b"}}}
+	var got []string
+	for _, a := range it.AllAttachments() {
+		got = append(got, a.URL)
+	}
+	for _, a := range it.AllAttachments() {
+		got = append(got, a.URL)
+		break
+	}
+	if want := []string{"a", "b", "a"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("AllAttachments() = %q, want %q", got, want)
+	}
+}
+
+func TestDecodeItems(t *testing.T) {
+	cases := []struct {
+		in   string
+		want []string
+	}{
+		{`{"version":"https://jsonfeed.org/version/1","author":{"name":"kr"},"items":[{"id":"2347259","content_text":"Cats"}]}`, []string{"2347259"}},
+		{`{"title":"t","items":[{"id":"a","content_text":"a"},{"id":1,"content_html":"b"}],"x":{`, []string{"a", "1"}},
+		{`{"title":"t"}`, nil},
+		{`{"items":null}`, nil},
+		{`{"items":[]}`, nil},
+	}
+	for _, test := range cases {
+		var got []string
+		for it, err := range DecodeItems(strings.NewReader(test.in)) {
+			if err != nil {
+				t.Errorf("DecodeItems(%q) error %v", test.in, err)
+				break
+			}
+			got = append(got, it.ID)
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("DecodeItems(%q) = %q, want %q", test.in, got, test.want)
+		}
+	}
+}
+
+func TestDecodeItemsStop(t *testing.T) {
+	// The reader fails after the first item,
+	// but we stop before reading that far.
+	r := iotest.DataErrReader(strings.NewReader(`{"items":[{"id":"a","content_text":"a"},`))
+	r = iotest.OneByteReader(r)
+	n := 0
+	for _, err := range DecodeItems(r) {
+		if err != nil {
+			t.Fatalf("DecodeItems() error %v", err)
+		}
+		n++
+		break
+	}
+	if n != 1 {
+		t.Errorf("DecodeItems() yielded %d items, want 1", n)
+	}
+}
+
+func TestDecodeItemsBad(t *testing.T) {
+	cases := []string{
+		``,
+		`[]`,
+		`{`,
+		`{"title"`,
+		`{"title":}`,
+		`{"items"`,
+		`{"items":{}}`,
+		`{"items":[{"id":"a","content_text":"a"},{"id":"b"}]}`,
+		`{"items":[{"id":"a","content_text":"a"},{"id":}]}`,
+	}
+	for _, in := range cases {
+		var err error
+		for _, err = range DecodeItems(strings.NewReader(in)) {
+			if err != nil {
+				break
+			}
+		}
+		if err == nil {
+			t.Errorf("DecodeItems(%q) error = nil, want error", in)
+		}
+	}
+
+	r := iotest.ErrReader(errors.New("read"))
+	for _, err := range DecodeItems(r) {
+		if err == nil || err.Error() != "read" {
+			t.Errorf("DecodeItems(ErrReader) = %v, want read", err)
+		}
+	}
+}
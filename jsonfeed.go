@@ -1,6 +1,7 @@
 package jsonfeed
 
 import (
+	"encoding/json"
 	"time"
 )
 
@@ -159,6 +160,15 @@ type Item struct {
 	// unique identifiers.
 	ID string `json:"id"`
 
+	// RawID holds the JSON text of the id
+	// if it was not a string, such as the number 1e3.
+	// When unmarshaling, ID is then set to that text,
+	// except that a number is normalized to plain decimal
+	// without an exponent or extra zeros, such as 1000,
+	// so numbers that are equal make the same ID.
+	// RawID is not marshaled directly, but see MarshalOptions.
+	RawID json.RawMessage `json:"-"`
+
 	// URL is the URL of the resource described by the item.
 	// It’s the permalink. This may be the same as the id —
 	// but should be present regardless.
@@ -243,6 +253,13 @@ type Item struct {
 	// instance, would include an attachment that’s an audio
 	// or video file.
 	Attachments []Attachment `json:"attachments,omitempty"`
+
+	// Extensions holds custom fields, keyed by name.
+	// Each name must begin with an underscore,
+	// as required by the spec, and each value must be valid JSON.
+	// When unmarshaling, any field whose name begins with
+	// an underscore is stored here.
+	Extensions map[string]json.RawMessage `json:"-"`
 }
 
 // Attachment represents a JSON Feed attachment.
@@ -267,15 +284,15 @@ type Attachment struct {
 	Title string `json:"title,omitempty"`
 
 	// SizeInBytes specifies how large the file is.
-	SizeInBytes int `json:"size_in_bytes,omitempty"`
+	SizeInBytes int64 `json:"size_in_bytes,omitempty"`
 
 	// DurationInSeconds specifies how long it takes to
 	// listen to or watch, when played at normal speed.
-	DurationInSeconds int `json:"duration_in_seconds,omitempty"`
+	DurationInSeconds float64 `json:"duration_in_seconds,omitempty"`
 }
 
 // Duration returns the duration stored in DurationInSeconds
 // as a Duration.
 func (a *Attachment) Duration() time.Duration {
-	return time.Duration(a.DurationInSeconds) * time.Second
+	return time.Duration(a.DurationInSeconds * float64(time.Second))
 }
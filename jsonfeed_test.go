@@ -15,3 +15,14 @@ func TestDuration(t *testing.T) {
 		t.Errorf("(%v).Duration() = %v want %v", a, got, want)
 	}
 }
+
+func TestDurationFraction(t *testing.T) {
+	a := &Attachment{
+		DurationInSeconds: 2.25,
+	}
+	got := a.Duration()
+	want := 2250 * time.Millisecond
+	if got != want {
+		t.Errorf("(%v).Duration() = %v want %v", a, got, want)
+	}
+}
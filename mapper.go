@@ -0,0 +1,388 @@
+package jsonfeed
+
+import (
+	"errors"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// A MapError describes a struct field that
+// ItemsFrom or ItemsInto cannot map to an Item field.
+type MapError struct {
+	Type  reflect.Type // the struct type
+	Field string       // the Go name of the field
+	Tag   string       // the jsonfeed tag of the field
+	Msg   string
+}
+
+func (e *MapError) Error() string {
+	return "jsonfeed: field " + e.Type.String() + "." + e.Field +
+		" (tag " + strconv.Quote(e.Tag) + "): " + e.Msg
+}
+
+var (
+	timeType       = reflect.TypeOf(time.Time{})
+	authorType     = reflect.TypeOf(Author{})
+	attachmentType = reflect.TypeOf(Attachment{})
+)
+
+// itemStringFields maps the JSON names of string fields in Item
+// to their Go names.
+var itemStringFields = map[string]string{
+	"url":          "URL",
+	"external_url": "ExternalURL",
+	"title":        "Title",
+	"content_html": "ContentHTML",
+	"content_text": "ContentText",
+	"summary":      "Summary",
+	"image":        "Image",
+	"banner_image": "BannerImage",
+}
+
+// ItemsFrom converts the elements of src to items.
+// Src must be a slice or array of structs or pointers to structs.
+// The struct fields to use are chosen by their struct tags
+// with key "jsonfeed", whose value is the JSON name
+// of the corresponding Item field.
+// For example:
+//
+//	type Post struct {
+//		Slug  string    `jsonfeed:"id"`
+//		Title string    `jsonfeed:"title"`
+//		Body  string    `jsonfeed:"content_html"`
+//		Date  time.Time `jsonfeed:"date_published"`
+//	}
+//
+// Fields tagged id, url, external_url, title, content_html,
+// content_text, summary, image, and banner_image must be strings,
+// except that id may also be an integer.
+// Fields tagged date_published and date_modified must be
+// time.Time or *time.Time.
+// A field tagged tags must be a slice of strings,
+// attachments must be []Attachment,
+// and author must be Author, *Author, or a string,
+// which is used as the author's name.
+// Untagged fields and nil pointers to structs are ignored.
+//
+// ItemsFrom does not validate the items it returns.
+func ItemsFrom(src interface{}) ([]Item, error) {
+	v := reflect.ValueOf(src)
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		return nil, errors.New("jsonfeed: ItemsFrom needs a slice or array, not " + typeString(v))
+	}
+	st := structType(v.Type().Elem())
+	if st == nil {
+		return nil, errors.New("jsonfeed: ItemsFrom needs elements of struct type, not " + v.Type().Elem().String())
+	}
+	fields, err := mapFields(st)
+	if err != nil {
+		return nil, err
+	}
+	var items []Item
+	for i := 0; i < v.Len(); i++ {
+		e := reflect.Indirect(v.Index(i))
+		if !e.IsValid() {
+			continue
+		}
+		var t Item
+		for _, f := range fields {
+			fv, err := e.FieldByIndexErr(f.index)
+			if err != nil {
+				continue // promoted through a nil embedded pointer
+			}
+			getField(&t, f.tag, fv)
+		}
+		items = append(items, t)
+	}
+	return items, nil
+}
+
+// ItemsInto converts items to structs,
+// the reverse of ItemsFrom,
+// and stores them in the slice pointed to by dst,
+// replacing its contents.
+// Dst must be a pointer to a slice of structs
+// or of pointers to structs,
+// with fields tagged as described for ItemsFrom.
+// An id field that is an integer receives
+// the item ID parsed as a decimal number;
+// it is an error if the ID is not a number.
+func ItemsInto(items []Item, dst interface{}) error {
+	p := reflect.ValueOf(dst)
+	if p.Kind() != reflect.Ptr || p.Elem().Kind() != reflect.Slice {
+		return errors.New("jsonfeed: ItemsInto needs a pointer to a slice, not " + typeString(p))
+	}
+	s := p.Elem()
+	et := s.Type().Elem()
+	st := structType(et)
+	if st == nil {
+		return errors.New("jsonfeed: ItemsInto needs elements of struct type, not " + et.String())
+	}
+	fields, err := mapFields(st)
+	if err != nil {
+		return err
+	}
+	out := reflect.MakeSlice(s.Type(), len(items), len(items))
+	for i := range items {
+		e := out.Index(i)
+		if et.Kind() == reflect.Ptr {
+			e.Set(reflect.New(st))
+			e = e.Elem()
+		}
+		for _, f := range fields {
+			fv, ok := fieldByIndexAlloc(e, f.index)
+			if !ok {
+				return &MapError{Type: st, Field: f.name, Tag: f.tag, Msg: "field is promoted through an unexported embedded pointer"}
+			}
+			err := setField(fv, f.tag, &items[i])
+			if err != nil {
+				return &MapError{Type: st, Field: f.name, Tag: f.tag, Msg: err.Error()}
+			}
+		}
+	}
+	s.Set(out)
+	return nil
+}
+
+// typeString returns the name of v's type,
+// or "nil" if v is the zero Value.
+func typeString(v reflect.Value) string {
+	if !v.IsValid() {
+		return "nil"
+	}
+	return v.Type().String()
+}
+
+// fieldByIndexAlloc is like v.FieldByIndex,
+// but it allocates any nil embedded pointers on the way.
+// It reports false if such a pointer cannot be set
+// because its field is unexported.
+func fieldByIndexAlloc(v reflect.Value, index []int) (reflect.Value, bool) {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				if !v.CanSet() {
+					return reflect.Value{}, false
+				}
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v, true
+}
+
+// structType returns the struct type for t,
+// which must be a struct or pointer to struct,
+// or nil if t is neither.
+func structType(t reflect.Type) reflect.Type {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil
+	}
+	return t
+}
+
+type mappedField struct {
+	name  string // Go name
+	tag   string // JSON name of the Item field
+	index []int
+}
+
+// mapFields returns the tagged fields of struct type t.
+// It returns a *MapError if any tagged field
+// cannot be mapped to its Item field.
+func mapFields(t reflect.Type) ([]mappedField, error) {
+	var fields []mappedField
+	seen := make(map[string]string)
+	for _, f := range reflect.VisibleFields(t) {
+		tag, ok := f.Tag.Lookup("jsonfeed")
+		if !ok || tag == "-" {
+			continue
+		}
+		if i := strings.IndexByte(tag, ','); i >= 0 {
+			tag = tag[:i]
+		}
+		fail := func(msg string) error {
+			return &MapError{Type: t, Field: f.Name, Tag: tag, Msg: msg}
+		}
+		if !f.IsExported() {
+			return nil, fail("field is unexported")
+		}
+		if other, ok := seen[tag]; ok {
+			return nil, fail("tag also used by field " + other)
+		}
+		seen[tag] = f.Name
+		if msg := checkFieldType(tag, f.Type); msg != "" {
+			return nil, fail(msg)
+		}
+		fields = append(fields, mappedField{name: f.Name, tag: tag, index: f.Index})
+	}
+	return fields, nil
+}
+
+// checkFieldType returns a description of the problem
+// if a field of type t cannot hold the Item field named tag,
+// or "" if it can.
+func checkFieldType(tag string, t reflect.Type) string {
+	ok := false
+	want := ""
+	switch tag {
+	case "id":
+		ok, want = t.Kind() == reflect.String || isInt(t) || isUint(t), "string or integer"
+	case "date_published", "date_modified":
+		ok, want = t == timeType || t == reflect.PtrTo(timeType), "time.Time or *time.Time"
+	case "tags":
+		ok, want = t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.String, "slice of strings"
+	case "author":
+		ok, want = t == authorType || t == reflect.PtrTo(authorType) || t.Kind() == reflect.String, "Author, *Author, or string"
+	case "attachments":
+		ok, want = t == reflect.SliceOf(attachmentType), "[]Attachment"
+	default:
+		if _, known := itemStringFields[tag]; !known {
+			return "unknown item field"
+		}
+		ok, want = t.Kind() == reflect.String, "string"
+	}
+	if !ok {
+		return "type " + t.String() + " is not " + want
+	}
+	return ""
+}
+
+func isInt(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return true
+	}
+	return false
+}
+
+func isUint(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return true
+	}
+	return false
+}
+
+// getField copies v into the field of t named by tag.
+// The type of v must already have been checked.
+func getField(t *Item, tag string, v reflect.Value) {
+	switch tag {
+	case "id":
+		switch {
+		case isInt(v.Type()):
+			t.ID = strconv.FormatInt(v.Int(), 10)
+		case isUint(v.Type()):
+			t.ID = strconv.FormatUint(v.Uint(), 10)
+		default:
+			t.ID = v.String()
+		}
+	case "date_published", "date_modified":
+		var d time.Time
+		if v.Kind() == reflect.Ptr {
+			if !v.IsNil() {
+				d = v.Elem().Interface().(time.Time)
+			}
+		} else {
+			d = v.Interface().(time.Time)
+		}
+		if tag == "date_published" {
+			t.DatePublished = d
+		} else {
+			t.DateModified = d
+		}
+	case "tags":
+		for i := 0; i < v.Len(); i++ {
+			t.Tags = append(t.Tags, v.Index(i).String())
+		}
+	case "author":
+		switch {
+		case v.Kind() == reflect.String:
+			if v.String() != "" {
+				t.Author = &Author{Name: v.String()}
+			}
+		case v.Kind() == reflect.Ptr:
+			if !v.IsNil() {
+				a := v.Elem().Interface().(Author)
+				t.Author = &a
+			}
+		default:
+			a := v.Interface().(Author)
+			t.Author = &a
+		}
+	case "attachments":
+		t.Attachments = append([]Attachment(nil), v.Interface().([]Attachment)...)
+	default:
+		reflect.ValueOf(t).Elem().FieldByName(itemStringFields[tag]).SetString(v.String())
+	}
+}
+
+// setField copies the field of t named by tag into v.
+// The type of v must already have been checked.
+func setField(v reflect.Value, tag string, t *Item) error {
+	switch tag {
+	case "id":
+		switch {
+		case isInt(v.Type()):
+			n, err := strconv.ParseInt(t.ID, 10, v.Type().Bits())
+			if err != nil {
+				return err
+			}
+			v.SetInt(n)
+		case isUint(v.Type()):
+			n, err := strconv.ParseUint(t.ID, 10, v.Type().Bits())
+			if err != nil {
+				return err
+			}
+			v.SetUint(n)
+		default:
+			v.SetString(t.ID)
+		}
+	case "date_published", "date_modified":
+		d := t.DatePublished
+		if tag == "date_modified" {
+			d = t.DateModified
+		}
+		if v.Kind() == reflect.Ptr {
+			if !d.IsZero() {
+				v.Set(reflect.ValueOf(&d))
+			}
+		} else {
+			v.Set(reflect.ValueOf(d))
+		}
+	case "tags":
+		if t.Tags == nil {
+			break
+		}
+		s := reflect.MakeSlice(v.Type(), len(t.Tags), len(t.Tags))
+		for i, tag := range t.Tags {
+			s.Index(i).SetString(tag)
+		}
+		v.Set(s)
+	case "author":
+		if t.Author == nil {
+			break
+		}
+		switch v.Kind() {
+		case reflect.String:
+			v.SetString(t.Author.Name)
+		case reflect.Ptr:
+			a := *t.Author
+			v.Set(reflect.ValueOf(&a))
+		default:
+			v.Set(reflect.ValueOf(*t.Author))
+		}
+	case "attachments":
+		v.Set(reflect.ValueOf(append([]Attachment(nil), t.Attachments...)))
+	default:
+		v.SetString(reflect.ValueOf(t).Elem().FieldByName(itemStringFields[tag]).String())
+	}
+	return nil
+}
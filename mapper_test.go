@@ -0,0 +1,268 @@
+package jsonfeed
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+type testTag string
+
+type testMeta struct {
+	Tags []testTag `jsonfeed:"tags"`
+}
+
+type testPost struct {
+	ID       int          `jsonfeed:"id"`
+	Link     string       `jsonfeed:"url"`
+	Title    string       `jsonfeed:"title,omitempty"`
+	Body     string       `jsonfeed:"content_html"`
+	Date     time.Time    `jsonfeed:"date_published"`
+	Edited   *time.Time   `jsonfeed:"date_modified"`
+	By       string       `jsonfeed:"author"`
+	Media    []Attachment `jsonfeed:"attachments"`
+	Draft    bool         // untagged
+	Internal string       `jsonfeed:"-"`
+	testMeta              // promoted fields are mapped too
+}
+
+type testNote struct {
+	ID     uint    `jsonfeed:"id"`
+	Text   string  `jsonfeed:"content_text"`
+	Author *Author `jsonfeed:"author"`
+}
+
+type testPage struct {
+	ID     string `jsonfeed:"id"`
+	Author Author `jsonfeed:"author"`
+}
+
+func TestItemsFrom(t *testing.T) {
+	date := time.Date(2017, 5, 17, 0, 0, 0, 0, time.UTC)
+	posts := []*testPost{
+		{
+			ID:       1,
+			Link:     "https://example.org/1",
+			Title:    "title",
+			Body:     "<p>body</p>",
+			Date:     date,
+			Edited:   &date,
+			By:       "kr",
+			Media:    []Attachment{{URL: "u", MIMEType: "m"}},
+			Draft:    true,
+			Internal: "x",
+			testMeta: testMeta{Tags: []testTag{"a", "b"}},
+		},
+		nil,
+		{ID: 2, Body: "<p>two</p>"},
+	}
+	got, err := ItemsFrom(posts)
+	if err != nil {
+		t.Fatalf("ItemsFrom(posts) = %v, want nil", err)
+	}
+	want := []Item{
+		{
+			ID:            "1",
+			URL:           "https://example.org/1",
+			Title:         "title",
+			ContentHTML:   "<p>body</p>",
+			DatePublished: date,
+			DateModified:  date,
+			Author:        &Author{Name: "kr"},
+			Attachments:   []Attachment{{URL: "u", MIMEType: "m"}},
+			Tags:          []string{"a", "b"},
+		},
+		{ID: "2", ContentHTML: "<p>two</p>"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ItemsFrom(posts) = %#v, want %#v", got, want)
+	}
+
+	var back []*testPost
+	err = ItemsInto(got, &back)
+	if err != nil {
+		t.Fatalf("ItemsInto(items) = %v, want nil", err)
+	}
+	wantBack := []*testPost{
+		{
+			ID:       1,
+			Link:     "https://example.org/1",
+			Title:    "title",
+			Body:     "<p>body</p>",
+			Date:     date,
+			Edited:   &date,
+			By:       "kr",
+			Media:    []Attachment{{URL: "u", MIMEType: "m"}},
+			testMeta: testMeta{Tags: []testTag{"a", "b"}},
+		},
+		{ID: 2, Body: "<p>two</p>"},
+	}
+	if !reflect.DeepEqual(back, wantBack) {
+		t.Errorf("ItemsInto(items) => %#v, want %#v", back, wantBack)
+	}
+}
+
+func TestItemsFromAuthors(t *testing.T) {
+	notes := [2]testNote{
+		{ID: 7, Text: "text", Author: &Author{Name: "a"}},
+		{ID: 8, Text: "text"},
+	}
+	got, err := ItemsFrom(notes)
+	if err != nil {
+		t.Fatalf("ItemsFrom(notes) = %v, want nil", err)
+	}
+	want := []Item{
+		{ID: "7", ContentText: "text", Author: &Author{Name: "a"}},
+		{ID: "8", ContentText: "text"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ItemsFrom(notes) = %#v, want %#v", got, want)
+	}
+	var back []testNote
+	if err := ItemsInto(got, &back); err != nil {
+		t.Fatalf("ItemsInto(items) = %v, want nil", err)
+	}
+	if !reflect.DeepEqual(back, notes[:]) {
+		t.Errorf("ItemsInto(items) => %#v, want %#v", back, notes)
+	}
+
+	pages := []testPage{{ID: "p", Author: Author{URL: "u"}}}
+	got, err = ItemsFrom(pages)
+	if err != nil {
+		t.Fatalf("ItemsFrom(pages) = %v, want nil", err)
+	}
+	var backPages []testPage
+	if err := ItemsInto(got, &backPages); err != nil {
+		t.Fatalf("ItemsInto(items) = %v, want nil", err)
+	}
+	if !reflect.DeepEqual(backPages, pages) {
+		t.Errorf("ItemsInto(items) => %#v, want %#v", backPages, pages)
+	}
+}
+
+type EmbeddedBase struct {
+	Title string `jsonfeed:"title"`
+}
+
+type testEmbedded struct {
+	ID string `jsonfeed:"id"`
+	*EmbeddedBase
+}
+
+type testBase struct {
+	Summary string `jsonfeed:"summary"`
+}
+
+type testUnexportedEmbedded struct {
+	ID string `jsonfeed:"id"`
+	*testBase
+}
+
+func TestItemsFromNilEmbedded(t *testing.T) {
+	src := []testEmbedded{{ID: "a"}, {ID: "b", EmbeddedBase: &EmbeddedBase{Title: "B"}}}
+	got, err := ItemsFrom(src)
+	if err != nil {
+		t.Fatalf("ItemsFrom() = %v, want nil", err)
+	}
+	want := []Item{{ID: "a"}, {ID: "b", Title: "B"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ItemsFrom() = %#v, want %#v", got, want)
+	}
+
+	var back []testEmbedded
+	if err := ItemsInto(got, &back); err != nil {
+		t.Fatalf("ItemsInto() = %v, want nil", err)
+	}
+	if back[0].EmbeddedBase == nil || back[1].Title != "B" {
+		t.Errorf("ItemsInto() => %#v, want allocated embedded pointers", back)
+	}
+
+	var unexported []testUnexportedEmbedded
+	if err := ItemsInto(got, &unexported); err == nil {
+		t.Errorf("ItemsInto(unexported embedded pointer) = nil, want error")
+	}
+	got, err = ItemsFrom([]testUnexportedEmbedded{{ID: "a"}})
+	if err != nil || len(got) != 1 || got[0].ID != "a" {
+		t.Errorf("ItemsFrom(unexported embedded pointer) = %#v, %v", got, err)
+	}
+}
+
+func TestItemsFromBad(t *testing.T) {
+	cases := []interface{}{
+		1,
+		[]int{1},
+		[]struct {
+			X int `jsonfeed:"title"`
+		}{{}},
+		[]struct {
+			X string `jsonfeed:"bogus"`
+		}{{}},
+		[]struct {
+			X float64 `jsonfeed:"id"`
+		}{{}},
+		[]struct {
+			X string `jsonfeed:"date_published"`
+		}{{}},
+		[]struct {
+			X []int `jsonfeed:"tags"`
+		}{{}},
+		[]struct {
+			X int `jsonfeed:"author"`
+		}{{}},
+		[]struct {
+			X []*Attachment `jsonfeed:"attachments"`
+		}{{}},
+		[]struct {
+			A string `jsonfeed:"title"`
+			B string `jsonfeed:"title"`
+		}{{}},
+		[]struct {
+			x string `jsonfeed:"title"`
+		}{{}},
+	}
+
+	for _, test := range cases {
+		_, err := ItemsFrom(test)
+		if err == nil {
+			t.Errorf("ItemsFrom(%#v) = nil, want error", test)
+		} else if err.Error() == "" {
+			t.Errorf("ItemsFrom(%#v) = empty error", test)
+		}
+	}
+}
+
+func TestItemsIntoBad(t *testing.T) {
+	items := []Item{{ID: "x"}}
+	var ints []int
+	var bad []struct {
+		X int `jsonfeed:"title"`
+	}
+	var signed []struct {
+		ID int8 `jsonfeed:"id"`
+	}
+	var unsigned []struct {
+		ID uint8 `jsonfeed:"id"`
+	}
+	cases := []interface{}{
+		nil,
+		items,
+		&ints,
+		&bad,
+		&signed,
+		&unsigned,
+	}
+
+	for _, test := range cases {
+		err := ItemsInto(items, test)
+		if err == nil {
+			t.Errorf("ItemsInto(items, %T) = nil, want error", test)
+		}
+	}
+
+	if err := ItemsInto(items, &ints); err == nil || err.Error() != "jsonfeed: ItemsInto needs elements of struct type, not int" {
+		t.Errorf("ItemsInto(items, &ints) = %v", err)
+	}
+	if _, err := ItemsFrom(nil); err == nil || err.Error() != "jsonfeed: ItemsFrom needs a slice or array, not nil" {
+		t.Errorf("ItemsFrom(nil) = %v", err)
+	}
+}
@@ -1,7 +1,14 @@
 package jsonfeed
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 )
 
@@ -10,6 +17,12 @@ import (
 // It always emits the version in Version,
 // regardless of the value in f.
 func (f *Feed) MarshalJSON() ([]byte, error) {
+	return f.marshal(false)
+}
+
+// marshal implements MarshalJSON.
+// If originalIDs is set, items are encoded as by originalIDItem.
+func (f *Feed) marshal(originalIDs bool) ([]byte, error) {
 	// TODO(kr): avoid copying all of f
 	f1 := new(Feed)
 	*f1 = *f
@@ -22,7 +35,33 @@ func (f *Feed) MarshalJSON() ([]byte, error) {
 		f1.Items = make([]Item, 0) // avoid emitting JSON 'null'
 	}
 	type t Feed // get rid of method MarshalJSON to avoid recursion
-	return json.Marshal((*t)(f1))
+	if !originalIDs {
+		return json.Marshal((*t)(f1))
+	}
+	items := make([]originalIDItem, len(f1.Items))
+	for i := range f1.Items {
+		items[i] = originalIDItem(f1.Items[i])
+	}
+	return json.Marshal(struct {
+		*t
+		Items []originalIDItem `json:"items"`
+	}{(*t)(f1), items})
+}
+
+// originalIDItem is an Item that marshals
+// with its RawID in place of its ID,
+// as long as the ID is still the one
+// that would be decoded from RawID.
+type originalIDItem Item
+
+func (t *originalIDItem) MarshalJSON() ([]byte, error) {
+	b, err := (*Item)(t).MarshalJSON()
+	if err != nil || len(t.RawID) == 0 || normalizeNumber(string(t.RawID)) != t.ID {
+		return b, err
+	}
+	id, _ := json.Marshal(t.ID)
+	prefix := len(`{"id":`) // the id is always the first field
+	return append(append(b[:prefix:prefix], t.RawID...), b[prefix+len(id):]...), nil
 }
 
 // UnmarshalJSON has the standard behavior for unmarshaling a struct,
@@ -36,22 +75,75 @@ func (f *Feed) UnmarshalJSON(b []byte) error {
 	return validFeed(f)
 }
 
+// MarshalJSON has the standard behavior for marshaling a struct,
+// except that it also emits the fields in t.Extensions.
+func (t *Item) MarshalJSON() ([]byte, error) {
+	type T Item // get rid of method MarshalJSON to avoid recursion
+	b, err := json.Marshal((*T)(t))
+	if err != nil || len(t.Extensions) == 0 {
+		return b, err
+	}
+	keys := make([]string, 0, len(t.Extensions))
+	for k := range t.Extensions {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var buf bytes.Buffer
+	buf.Write(b[:len(b)-1]) // drop the closing brace
+	for _, k := range keys {
+		v := t.Extensions[k]
+		if !strings.HasPrefix(k, "_") {
+			return nil, errors.New("jsonfeed: extension name " + strconv.Quote(k) + " does not begin with _")
+		}
+		if !json.Valid(v) {
+			return nil, errors.New("jsonfeed: extension " + k + " is not valid JSON")
+		}
+		kb, _ := json.Marshal(k)
+		buf.WriteByte(',')
+		buf.Write(kb)
+		buf.WriteByte(':')
+		buf.Write(v)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
 // UnmarshalJSON has the standard behavior for unmarshaling a struct,
 // except that it allows the id to be of any type,
 // converting it if necessary to a string,
-// as required by the spec,
+// as required by the spec, and keeping the original in t.RawID,
+// it stores extension fields in t.Extensions,
 // and it replaces missing dates with the current time.
 func (t *Item) UnmarshalJSON(b []byte) error {
 	type T Item // get rid of method UnmarshalJSON to avoid recursion
 	v := struct {
 		*T
-		ID anyString `json:"id"`
+		ID json.RawMessage `json:"id"`
 	}{T: (*T)(t)}
 	err := json.Unmarshal(b, &v)
 	if err != nil {
 		return err
 	}
-	t.ID = string(v.ID)
+	var id anyString
+	id.UnmarshalJSON(v.ID) // never fails
+	t.ID = string(id)
+	t.RawID = nil
+	if len(v.ID) > 0 && v.ID[0] != '"' && string(v.ID) != "null" {
+		t.RawID = v.ID
+	}
+	t.Extensions = nil
+	if bytes.Contains(b, []byte(`"_`)) {
+		var fields map[string]json.RawMessage
+		json.Unmarshal(b, &fields) // already known to be valid
+		for k, v := range fields {
+			if strings.HasPrefix(k, "_") {
+				if t.Extensions == nil {
+					t.Extensions = make(map[string]json.RawMessage)
+				}
+				t.Extensions[k] = v
+			}
+		}
+	}
 	if t.DatePublished.IsZero() {
 		t.DatePublished = time.Now().UTC()
 	}
@@ -61,14 +153,236 @@ func (t *Item) UnmarshalJSON(b []byte) error {
 	return nil
 }
 
+// UnmarshalJSON has the standard behavior for unmarshaling a struct,
+// except that it accepts size_in_bytes and duration_in_seconds
+// as strings holding numbers, as found in some feeds.
+// A fractional size is rounded to the nearest byte.
+// Negative numbers, and sizes too large for an int64,
+// are rejected with a *json.UnmarshalTypeError.
+func (a *Attachment) UnmarshalJSON(b []byte) error {
+	type T Attachment // get rid of method UnmarshalJSON to avoid recursion
+	v := struct {
+		*T
+		Size     json.RawMessage `json:"size_in_bytes"`
+		Duration json.RawMessage `json:"duration_in_seconds"`
+	}{T: (*T)(a)}
+	err := json.Unmarshal(b, &v)
+	if err != nil {
+		return err
+	}
+	size, err := number(v.Size, reflect.TypeFor[int64](), "size_in_bytes")
+	if err != nil {
+		return err
+	}
+	if size != "" {
+		a.SizeInBytes, err = strconv.ParseInt(size, 10, 64)
+		if err != nil {
+			f, _ := strconv.ParseFloat(size, 64) // already checked
+			if f >= math.MaxInt64 {
+				return typeError(v.Size, reflect.TypeFor[int64](), "size_in_bytes")
+			}
+			a.SizeInBytes = int64(math.Round(f))
+		}
+	}
+	duration, err := number(v.Duration, reflect.TypeFor[float64](), "duration_in_seconds")
+	if err != nil {
+		return err
+	}
+	if duration != "" {
+		a.DurationInSeconds, _ = strconv.ParseFloat(duration, 64) // already checked
+	}
+	return nil
+}
+
+// number returns the text of the finite, non-negative number in raw,
+// given in JSON as either a number or a string,
+// or "" if raw is empty or null.
+// If raw holds anything else, number returns an error
+// reporting that it is not a valid value of type t
+// for the named field.
+func number(raw json.RawMessage, t reflect.Type, field string) (string, error) {
+	s := string(raw)
+	if s == "" || s == "null" {
+		return "", nil
+	}
+	if strings.HasPrefix(s, `"`) {
+		json.Unmarshal(raw, &s) // already known to be valid
+		s = strings.TrimSpace(s)
+	}
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil || math.IsInf(f, 0) || math.IsNaN(f) || f < 0 {
+		return "", typeError(raw, t, field)
+	}
+	return s, nil
+}
+
+// typeError returns a *json.UnmarshalTypeError
+// for the JSON value raw.
+func typeError(raw json.RawMessage, t reflect.Type, field string) error {
+	value := "number " + string(raw)
+	switch raw[0] {
+	case '"':
+		value = "string"
+	case '{':
+		value = "object"
+	case '[':
+		value = "array"
+	case 't', 'f':
+		value = "bool"
+	}
+	return &json.UnmarshalTypeError{Value: value, Type: t, Field: field}
+}
+
 type anyString string
 
 func (s *anyString) UnmarshalJSON(b []byte) error {
 	err := json.Unmarshal(b, (*string)(s))
 	if err != nil {
 		// Not a valid JSON string, so fall back to
-		// the JSON text representation.
-		*s = anyString(b)
+		// the JSON text representation,
+		// with numbers normalized.
+		*s = anyString(normalizeNumber(string(b)))
+	}
+	return nil
+}
+
+// normalizeNumber returns the JSON number s
+// in a canonical form, so that numbers that are equal
+// have the same text.
+// It is written in decimal, without an exponent,
+// leading or trailing zeros, or a trailing decimal point,
+// and zero is "0".
+// For example, 1e3, 1000, and 1000.0 are all "1000",
+// and -0.50 is "-0.5".
+// Numbers too large or too small to write this way
+// in about maxNumberDigits digits,
+// and text that is not a JSON number,
+// are returned unchanged.
+func normalizeNumber(s string) string {
+	if s == "" || s[0] != '-' && (s[0] < '0' || s[0] > '9') {
+		return s
+	}
+	num, neg := strings.CutPrefix(s, "-")
+	mant, exp := num, 0
+	if i := strings.IndexAny(num, "eE"); i >= 0 {
+		var err error
+		mant = num[:i]
+		exp, err = strconv.Atoi(strings.TrimPrefix(num[i+1:], "+"))
+		if err != nil {
+			return s
+		}
+	}
+	whole, frac, _ := strings.Cut(mant, ".")
+	digits := whole + frac
+	point := len(whole) + exp // position of the decimal point in digits
+	trimmed := strings.TrimLeft(digits, "0")
+	point -= len(digits) - len(trimmed)
+	digits = strings.TrimRight(trimmed, "0")
+	if digits == "" {
+		return "0"
+	}
+	if point > maxNumberDigits || point < -maxNumberDigits {
+		return s
+	}
+	switch {
+	case point <= 0:
+		digits = "0." + strings.Repeat("0", -point) + digits
+	case point < len(digits):
+		digits = digits[:point] + "." + digits[point:]
+	default:
+		digits += strings.Repeat("0", point-len(digits))
+	}
+	if neg {
+		digits = "-" + digits
+	}
+	return digits
+}
+
+// maxNumberDigits limits the length of numbers written
+// by normalizeNumber, so a small exponent can't make a huge ID.
+const maxNumberDigits = 100
+
+// UnmarshalOptions configures optional behavior for decoding a feed.
+// The zero value decodes the same way as json.Unmarshal,
+// except that an item date not in RFC 3339 format
+// is reported as a *FieldError giving its location.
+type UnmarshalOptions struct {
+	// FillContent causes ContentHTML or ContentText
+	// to be derived from the other one
+	// in each item that has only one of them.
+	// See Item.FillContent.
+	FillContent bool
+
+	// InheritAuthors causes each item with no author
+	// to get a copy of the feed's author.
+	// See Feed.InheritAuthors.
+	InheritAuthors bool
+
+	// LenientDates causes item dates in common formats
+	// other than RFC 3339 to be accepted:
+	// RFC 3339 with a space in place of the T,
+	// or with no seconds or no time zone (meaning UTC),
+	// a plain date, RFC 1123 and RFC 822 as used in RSS,
+	// and Unix times in seconds or milliseconds.
+	// A date with a time zone name
+	// other than UTC and those of RFC 822
+	// is an error, since its offset is unknown.
+	LenientDates bool
+
+	// Warn, if set, is called for each problem
+	// that was tolerated, such as a non-standard date
+	// accepted because of LenientDates.
+	Warn func(w *FieldError)
+}
+
+// Unmarshal parses the JSON Feed in b and stores the result in f,
+// applying the options in o.
+func (o UnmarshalOptions) Unmarshal(b []byte, f *Feed) error {
+	b, err := o.checkDates(b)
+	if err != nil {
+		return err
+	}
+	err = json.Unmarshal(b, f)
+	if err != nil {
+		return err
+	}
+	if o.FillContent {
+		for i := range f.Items {
+			f.Items[i].FillContent()
+		}
+	}
+	if o.InheritAuthors {
+		f.InheritAuthors()
 	}
 	return nil
 }
+
+// MarshalOptions configures optional behavior for encoding a feed.
+// The zero value encodes the same way as json.Marshal.
+type MarshalOptions struct {
+	// CompactAuthors causes item authors equal to the feed's author
+	// to be omitted from the output.
+	// See Feed.CompactAuthors.
+	CompactAuthors bool
+
+	// OriginalIDs causes each item ID that was decoded
+	// from something other than a JSON string,
+	// such as a number, to be encoded in its original form,
+	// from Item.RawID, instead of as a string.
+	// An item whose ID has been changed since
+	// is encoded as a string as usual.
+	OriginalIDs bool
+}
+
+// Marshal returns the JSON encoding of f,
+// applying the options in o.
+// F itself is not modified.
+func (o MarshalOptions) Marshal(f *Feed) ([]byte, error) {
+	if o.CompactAuthors {
+		f1 := *f
+		f1.Items = append([]Item(nil), f.Items...)
+		f1.CompactAuthors()
+		f = &f1
+	}
+	return f.marshal(o.OriginalIDs)
+}
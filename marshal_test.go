@@ -3,7 +3,9 @@ package jsonfeed
 import (
 	"bytes"
 	"encoding/json"
+	"math"
 	"reflect"
+	"strings"
 	"testing"
 	"time"
 )
@@ -13,6 +15,7 @@ func TestAnyString(t *testing.T) {
 		{`"s"`, `s`},
 		{`true`, `true`},
 		{`12345`, `12345`},
+		{`1e3`, `1000`},
 		{`[1, 2]`, `[1, 2]`},
 		{`{"a": 1}`, `{"a": 1}`},
 	}
@@ -42,7 +45,15 @@ func TestUnmarshalItemOk(t *testing.T) {
 		},
 		{
 			`{"id": 12345, "content_text": "text"}`,
-			Item{ID: "12345", ContentText: "text"},
+			Item{ID: "12345", RawID: json.RawMessage("12345"), ContentText: "text"},
+		},
+		{
+			`{"id": 1.5e3, "content_text": "text"}`,
+			Item{ID: "1500", RawID: json.RawMessage("1.5e3"), ContentText: "text"},
+		},
+		{
+			`{"id": null, "content_text": "text"}`,
+			Item{ContentText: "text"},
 		},
 	}
 
@@ -168,3 +179,258 @@ func TestMarshalFeedBad(t *testing.T) {
 		t.Fatalf("Marshal(%#v) = nil, want error", f)
 	}
 }
+
+func TestUnmarshalOptionsFillContent(t *testing.T) {
+	b := []byte(`{
+		"version": "https://jsonfeed.org/version/1",
+		"title": "title",
+		"items": [{"id": "id", "content_html": "<p>a</p>"}]
+	}`)
+	var f Feed
+	err := UnmarshalOptions{FillContent: true}.Unmarshal(b, &f)
+	if err != nil {
+		t.Fatalf("Unmarshal(%q) = %v, want nil", b, err)
+	}
+	if got, want := f.Items[0].ContentText, "a"; got != want {
+		t.Errorf("Unmarshal(%q) => content_text %q, want %q", b, got, want)
+	}
+}
+
+func TestUnmarshalOptionsBad(t *testing.T) {
+	b := []byte(`xxx`) // invalid JSON
+	var f Feed
+	err := UnmarshalOptions{}.Unmarshal(b, &f)
+	if err == nil {
+		t.Fatalf("Unmarshal(%q) = nil, want error", b)
+	}
+}
+
+func TestItemExtensions(t *testing.T) {
+	b := []byte(`{"id":"id","content_text":"text","_a":{"x":1},"_b":[true],"c":1}`)
+	var got Item
+	err := json.Unmarshal(b, &got)
+	if err != nil {
+		t.Fatalf("Item.UnmarshalJSON(%q) = %v, want nil", b, err)
+	}
+	want := map[string]json.RawMessage{
+		"_a": json.RawMessage(`{"x":1}`),
+		"_b": json.RawMessage(`[true]`),
+	}
+	if !reflect.DeepEqual(got.Extensions, want) {
+		t.Errorf("Item.UnmarshalJSON(%q) => extensions %q, want %q", b, got.Extensions, want)
+	}
+
+	got.DatePublished = time.Time{}
+	got.DateModified = time.Time{}
+	enc, err := json.Marshal(&got)
+	if err != nil {
+		t.Fatalf("Item.MarshalJSON() = %v, want nil", err)
+	}
+	wantEnc := `{"id":"id","content_text":"text","date_published":"0001-01-01T00:00:00Z","date_modified":"0001-01-01T00:00:00Z","_a":{"x":1},"_b":[true]}`
+	if string(enc) != wantEnc {
+		t.Errorf("Item.MarshalJSON() = %s, want %s", enc, wantEnc)
+	}
+}
+
+func TestItemExtensionsBad(t *testing.T) {
+	cases := []map[string]json.RawMessage{
+		{"a": json.RawMessage(`1`)},  // no underscore
+		{"_a": json.RawMessage(`{`)}, // invalid JSON
+	}
+	for _, ext := range cases {
+		it := &Item{ID: "id", ContentText: "text", Extensions: ext}
+		_, err := json.Marshal(it)
+		if err == nil {
+			t.Errorf("Item.MarshalJSON() with extensions %q = nil, want error", ext)
+		}
+	}
+}
+
+func TestItemMarshalBad(t *testing.T) {
+	it := &Item{DatePublished: time.Date(-1, 1, 1, 0, 0, 0, 0, time.UTC)} // can't encode
+	_, err := it.MarshalJSON()
+	if err == nil {
+		t.Errorf("Item.MarshalJSON() = nil, want error")
+	}
+}
+
+func TestUnmarshalOptionsInheritAuthors(t *testing.T) {
+	b := []byte(`{
+		"version": "https://jsonfeed.org/version/1",
+		"title": "title",
+		"author": {"name": "kr"},
+		"items": [{"id": "id", "content_text": "a"}]
+	}`)
+	var f Feed
+	err := UnmarshalOptions{InheritAuthors: true}.Unmarshal(b, &f)
+	if err != nil {
+		t.Fatalf("Unmarshal(%q) = %v, want nil", b, err)
+	}
+	if got, want := f.Items[0].Author, (&Author{Name: "kr"}); !reflect.DeepEqual(got, want) {
+		t.Errorf("Unmarshal(%q) => author %v, want %v", b, got, want)
+	}
+}
+
+func TestMarshalOptions(t *testing.T) {
+	f := &Feed{
+		Version: Version,
+		Title:   "title",
+		Author:  &Author{Name: "kr"},
+		Items: []Item{
+			{ID: "a", ContentText: "a", Author: &Author{Name: "kr"}},
+			{ID: "b", ContentText: "b", Author: &Author{Name: "guest"}},
+		},
+	}
+	got, err := MarshalOptions{CompactAuthors: true}.Marshal(f)
+	if err != nil {
+		t.Fatalf("Marshal() = %v, want nil", err)
+	}
+	want := `{"version":"https://jsonfeed.org/version/1","title":"title","author":{"name":"kr"},` +
+		`"items":[{"id":"a","content_text":"a","date_published":"0001-01-01T00:00:00Z","date_modified":"0001-01-01T00:00:00Z"},` +
+		`{"id":"b","content_text":"b","date_published":"0001-01-01T00:00:00Z","date_modified":"0001-01-01T00:00:00Z","author":{"name":"guest"}}]}`
+	if string(got) != want {
+		t.Errorf("Marshal() = %s, want %s", got, want)
+	}
+	if f.Items[0].Author == nil {
+		t.Errorf("Marshal() modified its input")
+	}
+
+	plain, _ := MarshalOptions{}.Marshal(f)
+	if b, _ := json.Marshal(f); string(plain) != string(b) {
+		t.Errorf("MarshalOptions{}.Marshal() = %s, want %s", plain, b)
+	}
+}
+
+func TestUnmarshalAttachment(t *testing.T) {
+	cases := []struct {
+		in   string
+		want Attachment
+	}{
+		{`{"url":"u","size_in_bytes":3000000000,"duration_in_seconds":61}`, Attachment{URL: "u", SizeInBytes: 3000000000, DurationInSeconds: 61}},
+		{`{"size_in_bytes":9007199254740993}`, Attachment{SizeInBytes: 9007199254740993}},
+		{`{"size_in_bytes":1.2e3,"duration_in_seconds":61.5}`, Attachment{SizeInBytes: 1200, DurationInSeconds: 61.5}},
+		{`{"size_in_bytes":10.5}`, Attachment{SizeInBytes: 11}},
+		{`{"size_in_bytes":"1024","duration_in_seconds":" 2.5 "}`, Attachment{SizeInBytes: 1024, DurationInSeconds: 2.5}},
+		{`{"size_in_bytes":null,"duration_in_seconds":null}`, Attachment{}},
+		{`{"size_in_bytes":9223372036854775807,"duration_in_seconds":0}`, Attachment{SizeInBytes: math.MaxInt64}},
+		{`{"size_in_bytes":-0,"duration_in_seconds":"-0"}`, Attachment{}},
+	}
+	for _, test := range cases {
+		var got Attachment
+		err := json.Unmarshal([]byte(test.in), &got)
+		if err != nil {
+			t.Errorf("Unmarshal(%q) = %v, want nil", test.in, err)
+			continue
+		}
+		if got != test.want {
+			t.Errorf("Unmarshal(%q) = %+v, want %+v", test.in, got, test.want)
+		}
+	}
+
+	bad := []struct {
+		in    string
+		value string
+		field string
+	}{
+		{`{"size_in_bytes":"big"}`, "string", "size_in_bytes"},
+		{`{"duration_in_seconds":"NaN"}`, "string", "duration_in_seconds"},
+		{`{"duration_in_seconds":"1e999"}`, "string", "duration_in_seconds"},
+		{`{"duration_in_seconds":true}`, "bool", "duration_in_seconds"},
+		{`{"duration_in_seconds":{}}`, "object", "duration_in_seconds"},
+		{`{"duration_in_seconds":[]}`, "array", "duration_in_seconds"},
+		{`{"duration_in_seconds":-1}`, "number -1", "duration_in_seconds"},
+		{`{"size_in_bytes":-1}`, "number -1", "size_in_bytes"},
+		{`{"size_in_bytes":"-0.5"}`, "string", "size_in_bytes"},
+		{`{"size_in_bytes":9223372036854775808}`, "number 9223372036854775808", "size_in_bytes"},
+		{`{"size_in_bytes":1e300}`, "number 1e300", "size_in_bytes"},
+		{`{"url":1}`, "number", "url"},
+	}
+	for _, test := range bad {
+		var a Attachment
+		err := json.Unmarshal([]byte(test.in), &a)
+		te, ok := err.(*json.UnmarshalTypeError)
+		if !ok {
+			t.Errorf("Unmarshal(%q) = %v, want *json.UnmarshalTypeError", test.in, err)
+			continue
+		}
+		if te.Value != test.value || te.Field != test.field {
+			t.Errorf("Unmarshal(%q) = %v (value %q, field %q), want value %q, field %q",
+				test.in, err, te.Value, te.Field, test.value, test.field)
+		}
+	}
+}
+
+func TestMarshalAttachment(t *testing.T) {
+	a := Attachment{URL: "u", MIMEType: "audio/mpeg", SizeInBytes: 3000000000, DurationInSeconds: 3600}
+	b, err := json.Marshal(&a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"url":"u","mime_type":"audio/mpeg","size_in_bytes":3000000000,"duration_in_seconds":3600}`
+	if string(b) != want {
+		t.Errorf("Marshal(%+v) = %s, want %s", a, b, want)
+	}
+}
+
+func TestNormalizeNumber(t *testing.T) {
+	cases := []struct{ in, want string }{
+		{`0`, `0`},
+		{`-0.0e5`, `0`},
+		{`1000`, `1000`},
+		{`1e3`, `1000`},
+		{`1E+3`, `1000`},
+		{`1000.000`, `1000`},
+		{`10e-1`, `1`},
+		{`0012`, `12`},
+		{`-0.50`, `-0.5`},
+		{`1.25e-3`, `0.00125`},
+		{`123.456e1`, `1234.56`},
+		{`12345678901234567890123`, `12345678901234567890123`},
+		{`1e99`, `1` + strings.Repeat("0", 99)},
+		{`1e100`, `1e100`},
+		{`1e-102`, `1e-102`},
+		{`1e99999999999999999999`, `1e99999999999999999999`},
+		{`true`, `true`},
+		{`[1]`, `[1]`},
+		{``, ``},
+	}
+	for _, test := range cases {
+		if got := normalizeNumber(test.in); got != test.want {
+			t.Errorf("normalizeNumber(%q) = %q, want %q", test.in, got, test.want)
+		}
+	}
+}
+
+func TestMarshalOptionsOriginalIDs(t *testing.T) {
+	b := []byte(`{"version":"https://jsonfeed.org/version/1","title":"t","items":[` +
+		`{"id":1e3,"content_text":"a","date_published":"2017-05-17T00:00:00Z","date_modified":"2017-05-17T00:00:00Z","_x":1},` +
+		`{"id":"b","content_text":"b","date_published":"2017-05-17T00:00:00Z","date_modified":"2017-05-17T00:00:00Z"},` +
+		`{"id":7,"content_text":"<c>","date_published":"2017-05-17T00:00:00Z","date_modified":"2017-05-17T00:00:00Z"}]}`)
+	var f Feed
+	if err := json.Unmarshal(b, &f); err != nil {
+		t.Fatal(err)
+	}
+	f.Items[2].ID = "changed"
+	got, err := MarshalOptions{OriginalIDs: true}.Marshal(&f)
+	if err != nil {
+		t.Fatalf("Marshal() = %v, want nil", err)
+	}
+	want := bytes.Replace(b, []byte(`"id":7,`), []byte(`"id":"changed",`), 1)
+	want = bytes.Replace(want, []byte("<c>"), []byte(`\u003cc\u003e`), 1)
+	if !bytes.Equal(got, want) {
+		t.Errorf("Marshal() = %s, want %s", got, want)
+	}
+
+	plain, _ := json.Marshal(&f)
+	if !bytes.Contains(plain, []byte(`"id":"1000"`)) {
+		t.Errorf("json.Marshal() = %s, want id \"1000\"", plain)
+	}
+
+	if _, err := (MarshalOptions{OriginalIDs: true}).Marshal(&Feed{}); err == nil {
+		t.Errorf("Marshal(invalid feed) = nil, want error")
+	}
+	f.Items[0].Extensions = map[string]json.RawMessage{"bad": nil}
+	if _, err := (MarshalOptions{OriginalIDs: true}).Marshal(&f); err == nil {
+		t.Errorf("Marshal(bad extension) = nil, want error")
+	}
+}
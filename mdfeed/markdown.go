@@ -0,0 +1,544 @@
+package mdfeed
+
+import (
+	"html"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// render converts Markdown source to HTML.
+// It supports the commonly used subset of the language:
+// ATX headings, paragraphs, block quotes,
+// ordered and unordered lists (which may nest),
+// fenced and indented code blocks, thematic breaks,
+// and inline emphasis, code spans, links, images,
+// autolinks, and hard line breaks.
+// Raw HTML in the source is escaped, not passed through,
+// and links to URLs with schemes other than
+// http, https, and mailto are rendered as plain text.
+func render(src string) string {
+	src = strings.ReplaceAll(src, "\r\n", "\n")
+	src = strings.ReplaceAll(src, "\t", "    ")
+	var b strings.Builder
+	renderBlocks(&b, strings.Split(src, "\n"))
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+func renderBlocks(b *strings.Builder, lines []string) {
+	for len(lines) > 0 {
+		line := lines[0]
+		trimmed := strings.TrimSpace(line)
+		indent := len(line) - len(strings.TrimLeft(line, " "))
+		switch {
+		case trimmed == "":
+			lines = lines[1:]
+		case indent >= 4:
+			lines = renderIndentedCode(b, lines)
+		case strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~"):
+			lines = renderFencedCode(b, lines)
+		case headingLevel(trimmed) > 0:
+			n := headingLevel(trimmed)
+			text := strings.TrimSpace(strings.TrimRight(trimmed[n:], "#"))
+			tag := "h" + strconv.Itoa(n)
+			b.WriteString("<" + tag + ">" + inline(text) + "</" + tag + ">\n")
+			lines = lines[1:]
+		case isRule(trimmed):
+			b.WriteString("<hr>\n")
+			lines = lines[1:]
+		case strings.HasPrefix(trimmed, ">"):
+			lines = renderQuote(b, lines)
+		case listMarker(line) != nil:
+			lines = renderList(b, lines)
+		default:
+			lines = renderParagraph(b, lines)
+		}
+	}
+}
+
+// headingLevel returns the level of the ATX heading s,
+// or 0 if s is not a heading.
+func headingLevel(s string) int {
+	n := 0
+	for n < len(s) && s[n] == '#' {
+		n++
+	}
+	if 1 <= n && n <= 6 && (n == len(s) || s[n] == ' ') {
+		return n
+	}
+	return 0
+}
+
+func isRule(s string) bool {
+	if len(s) < 3 {
+		return false
+	}
+	c := s[0]
+	if c != '-' && c != '*' && c != '_' {
+		return false
+	}
+	n := 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case c:
+			n++
+		case ' ':
+		default:
+			return false
+		}
+	}
+	return n >= 3
+}
+
+func renderIndentedCode(b *strings.Builder, lines []string) []string {
+	var code []string
+	for len(lines) > 0 {
+		line := lines[0]
+		if strings.TrimSpace(line) != "" && !strings.HasPrefix(line, "    ") {
+			break
+		}
+		code = append(code, strings.TrimPrefix(line, "    "))
+		lines = lines[1:]
+	}
+	for len(code) > 0 && strings.TrimSpace(code[len(code)-1]) == "" {
+		code = code[:len(code)-1]
+	}
+	b.WriteString("<pre><code>" + html.EscapeString(strings.Join(code, "\n")) + "\n</code></pre>\n")
+	return lines
+}
+
+func renderFencedCode(b *strings.Builder, lines []string) []string {
+	first := strings.TrimSpace(lines[0])
+	fence := first[:3]
+	lang := strings.TrimSpace(strings.TrimLeft(first, fence[:1]))
+	if i := strings.IndexByte(lang, ' '); i >= 0 {
+		lang = lang[:i]
+	}
+	lines = lines[1:]
+	var code []string
+	for len(lines) > 0 {
+		line := lines[0]
+		lines = lines[1:]
+		if strings.HasPrefix(strings.TrimSpace(line), fence) {
+			break
+		}
+		code = append(code, line)
+	}
+	b.WriteString("<pre><code")
+	if lang != "" {
+		b.WriteString(` class="language-` + html.EscapeString(lang) + `"`)
+	}
+	b.WriteString(">")
+	if len(code) > 0 {
+		b.WriteString(html.EscapeString(strings.Join(code, "\n")) + "\n")
+	}
+	b.WriteString("</code></pre>\n")
+	return lines
+}
+
+func renderQuote(b *strings.Builder, lines []string) []string {
+	var inner []string
+	for len(lines) > 0 {
+		s := strings.TrimSpace(lines[0])
+		if !strings.HasPrefix(s, ">") {
+			break
+		}
+		s = strings.TrimPrefix(s, ">")
+		s = strings.TrimPrefix(s, " ")
+		inner = append(inner, s)
+		lines = lines[1:]
+	}
+	b.WriteString("<blockquote>\n")
+	renderBlocks(b, inner)
+	b.WriteString("</blockquote>\n")
+	return lines
+}
+
+// A marker is a list item marker found by listMarker.
+type marker struct {
+	ordered bool
+	start   int // for ordered lists
+	width   int // bytes up to the item's content
+}
+
+// listMarker returns the list item marker at the start of line,
+// or nil if line does not begin a list item.
+func listMarker(line string) *marker {
+	i := 0
+	for i < len(line) && i < 4 && line[i] == ' ' {
+		i++
+	}
+	if i == len(line) {
+		return nil
+	}
+	m := new(marker)
+	switch c := line[i]; {
+	case c == '-' || c == '*' || c == '+':
+		i++
+	case '0' <= c && c <= '9':
+		j := i
+		for j < len(line) && '0' <= line[j] && line[j] <= '9' {
+			j++
+		}
+		if j == len(line) || j-i > 9 || (line[j] != '.' && line[j] != ')') {
+			return nil
+		}
+		m.ordered = true
+		m.start, _ = strconv.Atoi(line[i:j])
+		i = j + 1
+	default:
+		return nil
+	}
+	if i < len(line) && line[i] != ' ' {
+		return nil
+	}
+	for i < len(line) && line[i] == ' ' {
+		i++
+	}
+	m.width = i
+	return m
+}
+
+func renderList(b *strings.Builder, lines []string) []string {
+	first := listMarker(lines[0])
+	tag := "ul"
+	if first.ordered {
+		tag = "ol"
+	}
+	b.WriteString("<" + tag)
+	if first.ordered && first.start != 1 {
+		b.WriteString(` start="` + strconv.Itoa(first.start) + `"`)
+	}
+	b.WriteString(">\n")
+
+	// Gather each item's lines, with the marker
+	// or continuation indent removed.
+	var items [][]string
+	loose := false
+	for len(lines) > 0 {
+		m := listMarker(lines[0])
+		if m == nil || m.ordered != first.ordered || isRule(strings.TrimSpace(lines[0])) {
+			break
+		}
+		item := []string{lines[0][m.width:]}
+		lines = lines[1:]
+		for len(lines) > 0 {
+			line := lines[0]
+			if strings.TrimSpace(line) == "" {
+				// A blank line continues the item
+				// only if indented content follows.
+				j := 1
+				for j < len(lines) && strings.TrimSpace(lines[j]) == "" {
+					j++
+				}
+				if j < len(lines) && strings.HasPrefix(lines[j], "  ") {
+					item = append(item, lines[:j]...)
+					lines = lines[j:]
+					loose = true
+					continue
+				}
+				if j < len(lines) && listMarker(lines[j]) != nil {
+					loose = true
+				}
+				lines = lines[j:]
+				break
+			}
+			if strings.HasPrefix(line, "  ") {
+				item = append(item, dedent(line, m.width))
+				lines = lines[1:]
+				continue
+			}
+			if listMarker(line) != nil || !isLazy(line) {
+				break
+			}
+			item = append(item, line) // lazy continuation
+			lines = lines[1:]
+		}
+		items = append(items, item)
+	}
+
+	for _, item := range items {
+		var ib strings.Builder
+		renderBlocks(&ib, item)
+		s := ib.String()
+		if !loose {
+			s = unwrapParagraphs(s)
+		}
+		b.WriteString("<li>" + strings.TrimSuffix(s, "\n") + "</li>\n")
+	}
+	b.WriteString("</" + tag + ">\n")
+	return lines
+}
+
+// isLazy reports whether line can continue a paragraph
+// inside a list item without being indented.
+func isLazy(line string) bool {
+	s := strings.TrimSpace(line)
+	return headingLevel(s) == 0 && !isRule(s) && !strings.HasPrefix(s, ">") &&
+		!strings.HasPrefix(s, "```") && !strings.HasPrefix(s, "~~~")
+}
+
+// dedent removes up to n leading spaces from line.
+func dedent(line string, n int) string {
+	i := 0
+	for i < n && i < len(line) && line[i] == ' ' {
+		i++
+	}
+	return line[i:]
+}
+
+// unwrapParagraphs removes the p tags from the start
+// and end of each paragraph in the rendered HTML s,
+// for tight lists.
+func unwrapParagraphs(s string) string {
+	s = strings.ReplaceAll(s, "<p>", "")
+	return strings.ReplaceAll(s, "</p>\n", "\n")
+}
+
+func renderParagraph(b *strings.Builder, lines []string) []string {
+	var para []string
+	for len(lines) > 0 {
+		line := lines[0]
+		s := strings.TrimSpace(line)
+		if s == "" || len(para) > 0 && (headingLevel(s) > 0 || isRule(s) ||
+			strings.HasPrefix(s, ">") || strings.HasPrefix(s, "```") ||
+			strings.HasPrefix(s, "~~~") || listMarker(line) != nil) {
+			break
+		}
+		para = append(para, line)
+		lines = lines[1:]
+	}
+	var text strings.Builder
+	for i, line := range para {
+		if i > 0 {
+			if strings.HasSuffix(para[i-1], "  ") || strings.HasSuffix(para[i-1], "\\") {
+				text.WriteString("<br>")
+			}
+			text.WriteString("\n")
+		}
+		line = strings.TrimSpace(line)
+		if i < len(para)-1 {
+			line = strings.TrimSuffix(line, "\\")
+		}
+		text.WriteString(inline(line))
+	}
+	b.WriteString("<p>" + text.String() + "</p>\n")
+	return lines
+}
+
+// inline renders the inline Markdown in s as HTML.
+func inline(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == '\\' && i+1 < len(s) && isASCIIPunct(s[i+1]):
+			b.WriteString(html.EscapeString(s[i+1 : i+2]))
+			i += 2
+			continue
+		case c == '`':
+			if out, n := codeSpan(s[i:]); n > 0 {
+				b.WriteString(out)
+				i += n
+				continue
+			}
+			n := strings.IndexFunc(s[i:], func(r rune) bool { return r != '`' })
+			if n < 0 {
+				n = len(s) - i
+			}
+			b.WriteString(s[i : i+n])
+			i += n
+			continue
+		case c == '!' && strings.HasPrefix(s[i+1:], "["):
+			if text, dest, n := link(s[i+1:]); n > 0 {
+				if !safeURL(dest) {
+					b.WriteString(html.EscapeString(text))
+				} else {
+					b.WriteString(`<img src="` + html.EscapeString(dest) + `" alt="` + html.EscapeString(text) + `">`)
+				}
+				i += 1 + n
+				continue
+			}
+		case c == '[':
+			if text, dest, n := link(s[i:]); n > 0 {
+				if !safeURL(dest) {
+					b.WriteString(inline(text))
+				} else {
+					b.WriteString(`<a href="` + html.EscapeString(dest) + `">` + inline(text) + `</a>`)
+				}
+				i += n
+				continue
+			}
+		case c == '<':
+			if j := strings.IndexByte(s[i:], '>'); j > 0 {
+				u := s[i+1 : i+j]
+				if isAutolink(u) && safeURL(u) {
+					e := html.EscapeString(u)
+					b.WriteString(`<a href="` + e + `">` + e + `</a>`)
+					i += j + 1
+					continue
+				}
+			}
+		case c == '*' || c == '_':
+			if out, n := emphasis(s, i); n > 0 {
+				b.WriteString(out)
+				i += n
+				continue
+			}
+			n := i
+			for n < len(s) && s[n] == c {
+				n++
+			}
+			b.WriteString(s[i:n])
+			i = n
+			continue
+		}
+		_, size := utf8.DecodeRuneInString(s[i:])
+		b.WriteString(html.EscapeString(s[i : i+size]))
+		i += size
+	}
+	return b.String()
+}
+
+func isASCIIPunct(c byte) bool {
+	return strings.IndexByte("!\"#$%&'()*+,-./:;<=>?@[\\]^_`{|}~", c) >= 0
+}
+
+func isAutolink(u string) bool {
+	i := strings.Index(u, ":")
+	if i < 2 || strings.ContainsAny(u, " <") {
+		return false
+	}
+	for _, r := range u[:i] {
+		if !(r < utf8.RuneSelf && (unicode.IsLetter(r) || unicode.IsDigit(r) || r == '+' || r == '.' || r == '-')) {
+			return false
+		}
+	}
+	return true
+}
+
+// safeURL reports whether u is relative
+// or has one of the schemes http, https, and mailto.
+// Links to other URLs, such as javascript:,
+// are rendered as plain text.
+func safeURL(u string) bool {
+	i := strings.IndexAny(u, ":/?#")
+	if i < 0 || u[i] != ':' {
+		return true
+	}
+	switch strings.ToLower(u[:i]) {
+	case "http", "https", "mailto":
+		return true
+	}
+	return false
+}
+
+// codeSpan renders the code span at the start of s.
+// It returns the number of bytes consumed,
+// or 0 if there is no matching closing backtick run.
+func codeSpan(s string) (string, int) {
+	n := 0
+	for n < len(s) && s[n] == '`' {
+		n++
+	}
+	delim := s[:n]
+	for i := n; i < len(s); {
+		j := strings.Index(s[i:], delim)
+		if j < 0 {
+			return "", 0
+		}
+		j += i
+		k := j + n
+		if k < len(s) && s[k] == '`' {
+			for k < len(s) && s[k] == '`' {
+				k++
+			}
+			i = k
+			continue
+		}
+		code := s[n:j]
+		if len(code) > 2 && code[0] == ' ' && code[len(code)-1] == ' ' {
+			code = code[1 : len(code)-1]
+		}
+		return "<code>" + html.EscapeString(code) + "</code>", k
+	}
+	return "", 0
+}
+
+// link parses a link of the form [text](dest) or [text](dest "title")
+// at the start of s. It returns the number of bytes consumed,
+// or 0 if s does not begin with a link.
+func link(s string) (text, dest string, n int) {
+	depth := 0
+	end := -1
+	for i := 0; i < len(s) && end < 0; i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '[':
+			depth++
+		case ']':
+			depth--
+			if depth == 0 {
+				end = i
+			}
+		}
+	}
+	if end < 0 || end+1 >= len(s) || s[end+1] != '(' {
+		return "", "", 0
+	}
+	j := strings.IndexByte(s[end+2:], ')')
+	if j < 0 {
+		return "", "", 0
+	}
+	inner := strings.TrimSpace(s[end+2 : end+2+j])
+	if strings.HasPrefix(inner, "<") && strings.Contains(inner, ">") {
+		inner = inner[1:strings.IndexByte(inner, '>')]
+	} else if k := strings.IndexByte(inner, ' '); k >= 0 {
+		inner = inner[:k] // drop the title
+	}
+	return s[1:end], inner, end + 2 + j + 1
+}
+
+// emphasis renders the emphasis starting at s[i].
+// It returns the number of bytes consumed,
+// or 0 if there is no emphasis there.
+func emphasis(s string, i int) (string, int) {
+	c := s[i]
+	n := 1
+	if i+1 < len(s) && s[i+1] == c {
+		n = 2
+	}
+	open := i + n
+	if open >= len(s) || s[open] == ' ' || s[open] == c {
+		return "", 0
+	}
+	if c == '_' && i > 0 && isWordByte(s[i-1]) {
+		return "", 0 // intraword underscores are literal
+	}
+	for j := open + 1; j < len(s); j++ {
+		if s[j] != c {
+			continue
+		}
+		r := 1 // length of this run of delimiters
+		for j+r < len(s) && s[j+r] == c {
+			r++
+		}
+		if r != n || s[j-1] == ' ' || s[j-1] == '\\' ||
+			c == '_' && j+n < len(s) && isWordByte(s[j+n]) {
+			j += r - 1
+			continue
+		}
+		tag := "em"
+		if n == 2 {
+			tag = "strong"
+		}
+		return "<" + tag + ">" + inline(s[open:j]) + "</" + tag + ">", j + n - i
+	}
+	return "", 0
+}
+
+func isWordByte(c byte) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' || c >= utf8.RuneSelf
+}
@@ -0,0 +1,103 @@
+package mdfeed
+
+import "testing"
+
+func TestRender(t *testing.T) {
+	cases := []struct{ md, html string }{
+		{"", ""},
+		{"Hello, *world*.", "<p>Hello, <em>world</em>.</p>"},
+		{"one\ntwo\n\nthree", "<p>one\ntwo</p>\n<p>three</p>"},
+		{"hard  \nbreak\\\nagain", "<p>hard<br>\nbreak<br>\nagain</p>"},
+		{"# H1\n## H2 ##\n###### H6\n####### no", "<h1>H1</h1>\n<h2>H2</h2>\n<h6>H6</h6>\n<p>####### no</p>"},
+		{"#nospace", "<p>#nospace</p>"},
+		{"Intro\n\n###\n#\n## ##", "<p>Intro</p>\n<h3></h3>\n<h1></h1>\n<h2></h2>"},
+		{"a\n# h", "<p>a</p>\n<h1>h</h1>"},
+		{"---\n* * *\n__", "<hr>\n<hr>\n<p>__</p>"},
+		{"--x", "<p>--x</p>"},
+		{"> quote\n> more\n\nafter", "<blockquote>\n<p>quote\nmore</p>\n</blockquote>\n<p>after</p>"},
+		{"```go\nx := 1 < 2\n```", "<pre><code class=\"language-go\">x := 1 &lt; 2\n</code></pre>"},
+		{"~~~\n~~~", "<pre><code></code></pre>"},
+		{"```go run\nunterminated", "<pre><code class=\"language-go\">unterminated\n</code></pre>"},
+		{"    code\n\n    more\n\ntext", "<pre><code>code\n\nmore\n</code></pre>\n<p>text</p>"},
+		{"\tcode", "<pre><code>code\n</code></pre>"},
+		{"- a\n- b\n* c", "<ul>\n<li>a</li>\n<li>b</li>\n<li>c</li>\n</ul>"},
+		{"1. a\n2. b", "<ol>\n<li>a</li>\n<li>b</li>\n</ol>"},
+		{"3) a", "<ol start=\"3\">\n<li>a</li>\n</ol>"},
+		{"- a\n\n- b", "<ul>\n<li><p>a</p></li>\n<li><p>b</p></li>\n</ul>"},
+		{"- a\n  - b\n  - c\n- d", "<ul>\n<li>a\n<ul>\n<li>b</li>\n<li>c</li>\n</ul></li>\n<li>d</li>\n</ul>"},
+		{"- a\n\n  more\n- b", "<ul>\n<li><p>a</p>\n<p>more</p></li>\n<li><p>b</p></li>\n</ul>"},
+		{"- a\nlazy\n# h", "<ul>\n<li>a\nlazy</li>\n</ul>\n<h1>h</h1>"},
+		{"- a\n\ntext", "<ul>\n<li>a</li>\n</ul>\n<p>text</p>"},
+		{"- a\n1. b", "<ul>\n<li>a</li>\n</ul>\n<ol>\n<li>b</li>\n</ol>"},
+		{"- a\n- - -", "<ul>\n<li>a</li>\n</ul>\n<hr>"},
+		{"- a\n\n", "<ul>\n<li>a</li>\n</ul>"},
+		{"-", "<ul>\n<li></li>\n</ul>"},
+		{"-x 1.x 1234567890. 2 ", "<p>-x 1.x 1234567890. 2</p>"},
+		{"     ", ""},
+		{"12", "<p>12</p>"},
+		{" - a\n   - b", "<ul>\n<li>a\n<ul>\n<li>b</li>\n</ul></li>\n</ul>"},
+		{"a\n- b", "<p>a</p>\n<ul>\n<li>b</li>\n</ul>"},
+		{"a\n> b", "<p>a</p>\n<blockquote>\n<p>b</p>\n</blockquote>"},
+		{"a\n```\nb\n```", "<p>a</p>\n<pre><code>b\n</code></pre>"},
+	}
+
+	for _, test := range cases {
+		got := render(test.md)
+		if got != test.html {
+			t.Errorf("render(%q) = %q, want %q", test.md, got, test.html)
+		}
+	}
+}
+
+func TestListMarkerBlank(t *testing.T) {
+	if m := listMarker("  "); m != nil {
+		t.Errorf("listMarker(%q) = %+v, want nil", "  ", m)
+	}
+}
+
+func TestInline(t *testing.T) {
+	cases := []struct{ md, html string }{
+		{`a < b & "c"`, `a &lt; b &amp; &#34;c&#34;`},
+		{`<b>raw</b>`, `&lt;b&gt;raw&lt;/b&gt;`},
+		{`\*not em\*`, `*not em*`},
+		{`\a`, `\a`},
+		{"`code <x>`", `<code>code &lt;x&gt;</code>`},
+		{"`` a`b ``", "<code>a`b</code>"},
+		{"``a```b``", "<code>a```b</code>"},
+		{"`unclosed", "`unclosed"},
+		{"``", "``"},
+		{"```a``", "```a``"},
+		{`**strong** and __strong__`, `<strong>strong</strong> and <strong>strong</strong>`},
+		{`*em* and _em_`, `<em>em</em> and <em>em</em>`},
+		{`*a **b** c*`, `<em>a <strong>b</strong> c</em>`},
+		{`snake_case_name`, `snake_case_name`},
+		{`_a_b_`, `<em>a_b</em>`},
+		{`* not em*`, `* not em*`},
+		{`*not em *`, `*not em *`},
+		{`*unclosed`, `*unclosed`},
+		{`***`, `***`},
+		{`*a\*`, `*a*`},
+		{`[link](https://example.org/ "title")`, `<a href="https://example.org/">link</a>`},
+		{`[*em* link](<https://example.org/a b>)`, `<a href="https://example.org/a b"><em>em</em> link</a>`},
+		{`[a [nested] \] link](/x)`, `<a href="/x">a [nested] ] link</a>`},
+		{`![alt "x"](/i.png)`, `<img src="/i.png" alt="alt &#34;x&#34;">`},
+		{`[no dest]`, `[no dest]`},
+		{`[unclosed`, `[unclosed`},
+		{`[x](unclosed`, `[x](unclosed`},
+		{`![no dest]`, `![no dest]`},
+		{`<https://example.org/?a=1&b=2>`, `<a href="https://example.org/?a=1&amp;b=2">https://example.org/?a=1&amp;b=2</a>`},
+		{`<mailto:me@example.org>`, `<a href="mailto:me@example.org">mailto:me@example.org</a>`},
+		{`<a:b> <x y:z> <é:x> <>`, `&lt;a:b&gt; &lt;x y:z&gt; &lt;é:x&gt; &lt;&gt;`},
+		{`<javascript:alert(1)> <JavaScript:x>`, `&lt;javascript:alert(1)&gt; &lt;JavaScript:x&gt;`},
+		{`[*x*](javascript:alert%281%29) ![y](data:image/png)`, `<em>x</em> y`},
+		{`[x](HTTPS://example.org/) [y](/a:b) [z](?q=a:b)`, `<a href="HTTPS://example.org/">x</a> <a href="/a:b">y</a> <a href="?q=a:b">z</a>`},
+		{`héllo wörld`, `héllo wörld`},
+	}
+
+	for _, test := range cases {
+		got := inline(test.md)
+		if got != test.html {
+			t.Errorf("inline(%q) = %q, want %q", test.md, got, test.html)
+		}
+	}
+}
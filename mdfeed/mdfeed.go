@@ -0,0 +1,313 @@
+/*
+Package mdfeed builds a JSON Feed from a directory of Markdown files.
+
+Each file whose name ends in ".md" or ".markdown" becomes one item.
+A file may begin with front matter,
+a block of "key: value" lines between two lines of "---",
+describing the item:
+
+	---
+	title: Hello, world
+	date: 2017-05-17T10:00:00-07:00
+	updated: 2017-05-18
+	tags: [greetings, meta]
+	id: tag:example.org,2017:hello
+	summary: The first post.
+	image: /images/hello.png
+	---
+	The body, in *Markdown*.
+
+The date is required.
+It may be in RFC 3339 format,
+or "2006-01-02 15:04" or "2006-01-02", meaning UTC.
+Tags may also be given one per line, each beginning with "- ".
+The body is rendered to HTML for ContentHTML,
+and kept verbatim as ContentText.
+
+Building a feed reads nothing but the given files
+and uses no clock,
+so the same input always produces the same feed.
+*/
+package mdfeed
+
+import (
+	"errors"
+	"io/fs"
+	"net/url"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kr/jsonfeed"
+)
+
+// Config holds feed-level settings for Build.
+type Config struct {
+	Title       string
+	HomePageURL string
+	FeedURL     string
+	Description string
+	Author      *jsonfeed.Author
+
+	// BaseURL, if set, is the URL under which the items
+	// are published. Each item's URL is BaseURL followed by
+	// the file's path, relative to the directory,
+	// without its extension.
+	// Relative image URLs are resolved against BaseURL.
+	BaseURL string
+}
+
+// Build reads every Markdown file in fsys,
+// including those in subdirectories,
+// and returns a validated feed of the resulting items,
+// newest first.
+// Files and directories whose names begin with "." or "_"
+// are skipped.
+//
+// An item's ID is taken from its front matter if present,
+// or else its URL, if BaseURL is set,
+// or else the file's path without its extension.
+func Build(fsys fs.FS, c *Config) (*jsonfeed.Feed, error) {
+	var base *url.URL
+	if c.BaseURL != "" {
+		u, err := url.Parse(c.BaseURL)
+		if err != nil {
+			return nil, err
+		}
+		if !strings.HasSuffix(u.Path, "/") {
+			// Keep the last path segment when resolving.
+			u.Path += "/"
+			if u.RawPath != "" {
+				u.RawPath += "/"
+			}
+		}
+		base = u
+	}
+	var items []jsonfeed.Item
+	err := fs.WalkDir(fsys, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if name != "." && (strings.HasPrefix(d.Name(), ".") || strings.HasPrefix(d.Name(), "_")) {
+			if d.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		ext := path.Ext(name)
+		if d.IsDir() || ext != ".md" && ext != ".markdown" {
+			return nil
+		}
+		src, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return err
+		}
+		t, err := ParseItem(name, src)
+		if err != nil {
+			return err
+		}
+		slug := strings.TrimSuffix(name, ext)
+		if base != nil {
+			t.URL = base.ResolveReference(&url.URL{Path: slug}).String()
+			if t.Image != "" {
+				t.Image = resolve(base, t.Image)
+			}
+		}
+		if t.ID == "" && t.URL == "" {
+			t.ID = slug
+		}
+		items = append(items, t)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(items, func(i, j int) bool {
+		a, b := items[i].DatePublished, items[j].DatePublished
+		if !a.Equal(b) {
+			return a.After(b)
+		}
+		return items[i].URL+items[i].ID < items[j].URL+items[j].ID
+	})
+
+	b := new(jsonfeed.Builder)
+	b.Title(c.Title)
+	if c.HomePageURL != "" {
+		b.HomePage(c.HomePageURL)
+	}
+	if c.FeedURL != "" {
+		b.FeedURL(c.FeedURL)
+	}
+	if c.Author != nil {
+		b.Author(*c.Author)
+	}
+	b.Description(c.Description)
+	for _, t := range items {
+		b.AddItem(t)
+	}
+	return b.Build()
+}
+
+func resolve(base *url.URL, ref string) string {
+	u, err := url.Parse(ref)
+	if err != nil {
+		return ref
+	}
+	return base.ResolveReference(u).String()
+}
+
+// A FileError records a problem with one Markdown file.
+type FileError struct {
+	Name string
+	Line int // 1-based; 0 if not applicable
+	Err  error
+}
+
+func (e *FileError) Error() string {
+	s := "mdfeed: " + e.Name
+	if e.Line > 0 {
+		s += ":" + strconv.Itoa(e.Line)
+	}
+	return s + ": " + e.Err.Error()
+}
+
+func (e *FileError) Unwrap() error {
+	return e.Err
+}
+
+// ParseItem parses the Markdown file src,
+// with its front matter, as an item.
+// Name is used only in error messages.
+// It does not set the item's URL,
+// nor its ID if the front matter has none.
+func ParseItem(name string, src []byte) (jsonfeed.Item, error) {
+	var t jsonfeed.Item
+	text := strings.ReplaceAll(string(src), "\r\n", "\n")
+	fail := func(line int, msg string) (jsonfeed.Item, error) {
+		return jsonfeed.Item{}, &FileError{Name: name, Line: line, Err: errors.New(msg)}
+	}
+	fm, body, ok := splitFrontMatter(text)
+	if !ok {
+		return fail(1, "unterminated front matter")
+	}
+	bodyLine := strings.Count(text[:len(text)-len(body)], "\n") + 1
+	var haveDate bool
+	lines := strings.Split(fm, "\n")
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		if strings.TrimSpace(line) == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		colon := strings.IndexByte(line, ':')
+		if colon < 0 {
+			return fail(i+2, "expected key: value")
+		}
+		key := strings.TrimSpace(line[:colon])
+		val := unquote(strings.TrimSpace(line[colon+1:]))
+		switch key {
+		case "title":
+			t.Title = val
+		case "id":
+			t.ID = val
+		case "summary":
+			t.Summary = val
+		case "image":
+			t.Image = val
+		case "date", "updated":
+			d, err := parseDate(val)
+			if err != nil {
+				return fail(i+2, "bad "+key+": "+val)
+			}
+			if key == "date" {
+				t.DatePublished = d
+				haveDate = true
+			} else {
+				t.DateModified = d
+			}
+		case "tags":
+			if val == "" {
+				for i+1 < len(lines) && strings.HasPrefix(strings.TrimSpace(lines[i+1]), "- ") {
+					i++
+					t.Tags = append(t.Tags, unquote(strings.TrimSpace(strings.TrimSpace(lines[i])[2:])))
+				}
+				break
+			}
+			if !strings.HasPrefix(val, "[") || !strings.HasSuffix(val, "]") {
+				t.Tags = append(t.Tags, val)
+				break
+			}
+			for _, tag := range strings.Split(val[1:len(val)-1], ",") {
+				if tag = unquote(strings.TrimSpace(tag)); tag != "" {
+					t.Tags = append(t.Tags, tag)
+				}
+			}
+		}
+	}
+	if !haveDate {
+		return fail(0, "no date in front matter")
+	}
+	if t.DateModified.IsZero() {
+		t.DateModified = t.DatePublished
+	}
+	body = strings.TrimSpace(body)
+	if body == "" {
+		return fail(bodyLine, "empty body")
+	}
+	t.ContentText = body
+	t.ContentHTML = render(body)
+	return t, nil
+}
+
+// splitFrontMatter splits text into its front matter, if any,
+// and body. It reports false if the front matter
+// is not terminated.
+func splitFrontMatter(text string) (fm, body string, ok bool) {
+	if !strings.HasPrefix(text, "---\n") {
+		return "", text, true
+	}
+	rest := text[len("---\n"):]
+	if strings.HasPrefix(rest, "---\n") || rest == "---" {
+		return "", strings.TrimPrefix(rest, "---"), true
+	}
+	i := strings.Index(rest, "\n---\n")
+	if i < 0 {
+		if strings.HasSuffix(rest, "\n---") {
+			return rest[:len(rest)-len("\n---")], "", true
+		}
+		return "", "", false
+	}
+	return rest[:i], rest[i+len("\n---\n"):], true
+}
+
+func unquote(s string) string {
+	if len(s) >= 2 && (s[0] == '"' || s[0] == '\'') && s[len(s)-1] == s[0] {
+		if s[0] == '"' {
+			if u, err := strconv.Unquote(s); err == nil {
+				return u
+			}
+		}
+		return s[1 : len(s)-1]
+	}
+	return s
+}
+
+var dateLayouts = []string{
+	time.RFC3339,
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02",
+}
+
+func parseDate(s string) (time.Time, error) {
+	var err error
+	for _, layout := range dateLayouts {
+		var d time.Time
+		d, err = time.Parse(layout, s)
+		if err == nil {
+			return d, nil
+		}
+	}
+	return time.Time{}, err
+}
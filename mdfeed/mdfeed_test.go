@@ -0,0 +1,228 @@
+package mdfeed
+
+import (
+	"errors"
+	"io/fs"
+	"reflect"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/kr/jsonfeed"
+)
+
+func TestBuild(t *testing.T) {
+	fsys := fstest.MapFS{
+		"hello.md":             {Data: []byte("---\ntitle: Hello\ndate: 2017-05-17\ntags: [a, \"b\"]\nimage: /img/hello.png\n---\nHi *there*.\n")},
+		"posts/later.markdown": {Data: []byte("---\ntitle: 'Later'\ndate: 2017-05-18 09:30\nupdated: 2017-05-19T00:00:00Z\ntags:\n  - x\n  - \"y\"\nid: custom\nsummary: Later on.\n---\nBody.")},
+		"same-day.md":          {Data: []byte("---\ndate: 2017-05-17\n---\nSame day.")},
+		"notes.txt":            {Data: []byte("ignored")},
+		".hidden.md":           {Data: []byte("ignored")},
+		"_drafts/draft.md":     {Data: []byte("ignored")},
+	}
+	c := &Config{
+		Title:       "Blog",
+		HomePageURL: "https://example.org/",
+		FeedURL:     "https://example.org/feed.json",
+		Description: "A blog.",
+		Author:      &jsonfeed.Author{Name: "kr"},
+		BaseURL:     "https://example.org/blog/",
+	}
+	got, err := Build(fsys, c)
+	if err != nil {
+		t.Fatalf("Build() = %v, want nil", err)
+	}
+	d17 := time.Date(2017, 5, 17, 0, 0, 0, 0, time.UTC)
+	want := &jsonfeed.Feed{
+		Version:     jsonfeed.Version,
+		Title:       "Blog",
+		HomePageURL: "https://example.org/",
+		FeedURL:     "https://example.org/feed.json",
+		Description: "A blog.",
+		Author:      &jsonfeed.Author{Name: "kr"},
+		Items: []jsonfeed.Item{
+			{
+				ID:            "custom",
+				URL:           "https://example.org/blog/posts/later",
+				Title:         "Later",
+				ContentHTML:   "<p>Body.</p>",
+				ContentText:   "Body.",
+				Summary:       "Later on.",
+				DatePublished: time.Date(2017, 5, 18, 9, 30, 0, 0, time.UTC),
+				DateModified:  time.Date(2017, 5, 19, 0, 0, 0, 0, time.UTC),
+				Tags:          []string{"x", "y"},
+			},
+			{
+				ID:            "https://example.org/blog/hello",
+				URL:           "https://example.org/blog/hello",
+				Title:         "Hello",
+				ContentHTML:   "<p>Hi <em>there</em>.</p>",
+				ContentText:   "Hi *there*.",
+				Image:         "https://example.org/img/hello.png",
+				DatePublished: d17,
+				DateModified:  d17,
+				Tags:          []string{"a", "b"},
+			},
+			{
+				ID:            "https://example.org/blog/same-day",
+				URL:           "https://example.org/blog/same-day",
+				ContentHTML:   "<p>Same day.</p>",
+				ContentText:   "Same day.",
+				DatePublished: d17,
+				DateModified:  d17,
+			},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Build() = %#v, want %#v", got, want)
+	}
+}
+
+func TestBuildNoBaseURL(t *testing.T) {
+	fsys := fstest.MapFS{
+		"a.md": {Data: []byte("---\ndate: 2017-05-17\n---\nA.")},
+	}
+	got, err := Build(fsys, &Config{Title: "t"})
+	if err != nil {
+		t.Fatalf("Build() = %v, want nil", err)
+	}
+	if g := got.Items[0]; g.ID != "a" || g.URL != "" {
+		t.Errorf("Build() item = %+v, want ID a and no URL", g)
+	}
+}
+
+func TestBuildBad(t *testing.T) {
+	good := fstest.MapFS{"a.md": {Data: []byte("---\ndate: 2017-05-17\n---\nA.")}}
+	cases := []struct {
+		fsys fs.FS
+		c    *Config
+	}{
+		{good, &Config{Title: "t", BaseURL: "%zz"}},
+		{good, &Config{}}, // no title
+		{good, &Config{Title: "t", HomePageURL: "relative"}},
+		{good, &Config{Title: "t", FeedURL: "relative"}},
+		{good, &Config{Title: "t", Author: &jsonfeed.Author{}}},
+		{fstest.MapFS{"a.md": {Data: []byte("no front matter")}}, &Config{Title: "t"}},
+		{readErrFS{good}, &Config{Title: "t"}},
+		{errFS{}, &Config{Title: "t"}},
+	}
+
+	for _, test := range cases {
+		_, err := Build(test.fsys, test.c)
+		if err == nil {
+			t.Errorf("Build(%v, %+v) = nil, want error", test.fsys, test.c)
+		}
+	}
+}
+
+// readErrFS is a file system whose directories can be listed
+// but whose files cannot be read.
+type readErrFS struct {
+	fstest.MapFS
+}
+
+func (fsys readErrFS) Open(name string) (fs.File, error) {
+	if name == "." {
+		return fsys.MapFS.Open(name)
+	}
+	return nil, errors.New("broken")
+}
+
+func (readErrFS) ReadFile(name string) ([]byte, error) {
+	return nil, errors.New("broken")
+}
+
+type errFS struct{}
+
+func (errFS) Open(name string) (fs.File, error) {
+	return nil, errors.New("broken")
+}
+
+func TestParseItemBad(t *testing.T) {
+	cases := []struct {
+		src  string
+		want string
+	}{
+		{"---\ndate: 2017-05-17\n", "mdfeed: a.md:1: unterminated front matter"},
+		{"---\ndate: 2017-05-17\nbogus\n---\nA.", "mdfeed: a.md:3: expected key: value"},
+		{"---\n\n# comment\ndate: tuesday\n---\nA.", "mdfeed: a.md:4: bad date: tuesday"},
+		{"---\nupdated: 2017-05-17\n---\nA.", "mdfeed: a.md: no date in front matter"},
+		{"---\ndate: 2017-05-17\n---\n\n  \n", "mdfeed: a.md:4: empty body"},
+		{"---\ndate: 2017-05-17\n---", "mdfeed: a.md:3: empty body"},
+		{"---\n---\nA.", "mdfeed: a.md: no date in front matter"},
+		{"---\n---", "mdfeed: a.md: no date in front matter"},
+	}
+
+	for _, test := range cases {
+		_, err := ParseItem("a.md", []byte(test.src))
+		if err == nil || err.Error() != test.want {
+			t.Errorf("ParseItem(%q) = %v, want %q", test.src, err, test.want)
+			continue
+		}
+		if errors.Unwrap(err) == nil {
+			t.Errorf("ParseItem(%q) error has no cause", test.src)
+		}
+	}
+}
+
+func TestParseItemTags(t *testing.T) {
+	src := "---\ndate: 2017-05-17\ntags: solo\n---\nA."
+	got, err := ParseItem("a.md", []byte(src))
+	if err != nil {
+		t.Fatalf("ParseItem(%q) = %v, want nil", src, err)
+	}
+	if want := []string{"solo"}; !reflect.DeepEqual(got.Tags, want) {
+		t.Errorf("ParseItem(%q) tags = %q, want %q", src, got.Tags, want)
+	}
+}
+
+func TestUnquote(t *testing.T) {
+	cases := []struct{ in, out string }{
+		{`"a\tb"`, "a\tb"},
+		{`"bad \q"`, `bad \q`},
+		{`'a\tb'`, `a\tb`},
+		{`"`, `"`},
+		{`"a'`, `"a'`},
+	}
+	for _, test := range cases {
+		if got := unquote(test.in); got != test.out {
+			t.Errorf("unquote(%q) = %q, want %q", test.in, got, test.out)
+		}
+	}
+}
+
+func TestResolveBad(t *testing.T) {
+	fsys := fstest.MapFS{
+		"a.md": {Data: []byte("---\ndate: 2017-05-17\nimage: \"%zz\"\n---\nA.")},
+	}
+	got, err := Build(fsys, &Config{Title: "t", BaseURL: "https://example.org/"})
+	if err != nil {
+		t.Fatalf("Build() = %v, want nil", err)
+	}
+	if g := got.Items[0].Image; g != "%zz" {
+		t.Errorf("Build() image = %q, want %q", g, "%zz")
+	}
+}
+
+func TestBuildBaseURLSlash(t *testing.T) {
+	fsys := fstest.MapFS{
+		"hello.md": {Data: []byte("---\ndate: 2017-05-17\n---\nHi.")},
+	}
+	cases := []struct {
+		base, want string
+	}{
+		{"https://example.org/blog", "https://example.org/blog/hello"},
+		{"https://example.org/blog/", "https://example.org/blog/hello"},
+		{"https://example.org", "https://example.org/hello"},
+		{"https://example.org/a%2Fb", "https://example.org/a%2Fb/hello"},
+	}
+	for _, test := range cases {
+		got, err := Build(fsys, &Config{Title: "t", BaseURL: test.base})
+		if err != nil {
+			t.Fatalf("Build(%q) = %v, want nil", test.base, err)
+		}
+		if g := got.Items[0].URL; g != test.want {
+			t.Errorf("Build(%q) URL = %q, want %q", test.base, g, test.want)
+		}
+	}
+}
@@ -0,0 +1,162 @@
+/*
+Package media reads the size, duration, and type of audio and video files
+for use in JSON Feed attachments.
+
+It understands MP3 (MPEG audio layer III,
+using a Xing, Info, or VBRI header if present,
+or else assuming a constant bit rate),
+MP4 and M4A (using the movie header),
+Ogg Opus and Ogg Vorbis (using the last granule position),
+and WAV files.
+It reads only the headers and, for Ogg, the end of the file,
+so it is fast even for large files.
+
+To fill in attachments as they are added to a feed,
+pass the function returned by FS to jsonfeed.Builder.FillAttachments.
+*/
+package media
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/fs"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/kr/jsonfeed"
+)
+
+// ErrUnknownFormat is returned when a file is not
+// in any of the formats this package understands.
+var ErrUnknownFormat = errors.New("media: unknown format")
+
+// Info describes a media file.
+type Info struct {
+	MIMEType string
+	Size     int64
+	Duration time.Duration
+}
+
+// Inspect reads the media file in r, which is size bytes long.
+func Inspect(r io.ReaderAt, size int64) (*Info, error) {
+	head, _ := readAt(r, 0, 12)
+	var (
+		typ string
+		d   time.Duration
+		err error
+	)
+	switch {
+	case len(head) >= 12 && string(head[4:8]) == "ftyp":
+		typ, d, err = mp4Info(r, size)
+	case len(head) >= 4 && string(head[:4]) == "OggS":
+		typ = "audio/ogg"
+		d, err = oggDuration(r, size)
+	case len(head) >= 12 && string(head[:4]) == "RIFF" && string(head[8:12]) == "WAVE":
+		typ = "audio/wav"
+		d, err = wavDuration(r, size)
+	case len(head) >= 3 && string(head[:3]) == "ID3",
+		len(head) >= 2 && head[0] == 0xff && head[1]&0xe0 == 0xe0:
+		typ = "audio/mpeg"
+		d, err = mp3Duration(r, size)
+	default:
+		return nil, ErrUnknownFormat
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &Info{MIMEType: typ, Size: size, Duration: d}, nil
+}
+
+// InspectFile reads the named media file.
+func InspectFile(name string) (*Info, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return inspectFile(f)
+}
+
+func inspectFile(f fs.File) (*Info, error) {
+	fi, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	r, ok := f.(io.ReaderAt)
+	if !ok {
+		b, err := io.ReadAll(f)
+		if err != nil {
+			return nil, err
+		}
+		r = bytes.NewReader(b)
+	}
+	return Inspect(r, fi.Size())
+}
+
+// Fill sets the SizeInBytes and DurationInSeconds of a from info.
+// It sets the MIMEType too, unless a already has one.
+func (info *Info) Fill(a *jsonfeed.Attachment) {
+	a.SizeInBytes = info.Size
+	a.DurationInSeconds = info.Duration.Seconds()
+	if a.MIMEType == "" {
+		a.MIMEType = info.MIMEType
+	}
+}
+
+// FS returns a function that fills in attachments
+// whose URLs are under baseURL,
+// using the files in fsys they refer to.
+// A URL is under baseURL if it begins with baseURL
+// and, unless baseURL ends in a slash,
+// continues with a slash or not at all.
+// The rest of the URL, unescaped, is the name of the file in fsys.
+// Other attachments are left alone.
+// See Info.Fill for which fields are set.
+//
+// The function is meant to be passed to jsonfeed.Builder.FillAttachments.
+func FS(fsys fs.FS, baseURL string) func(a *jsonfeed.Attachment) error {
+	return func(a *jsonfeed.Attachment) error {
+		rest, ok := strings.CutPrefix(a.URL, baseURL)
+		if !ok || !strings.HasSuffix(baseURL, "/") && rest != "" && rest[0] != '/' {
+			return nil
+		}
+		name, err := url.PathUnescape(strings.TrimPrefix(rest, "/"))
+		if err != nil {
+			return err
+		}
+		f, err := fsys.Open(name)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		info, err := inspectFile(f)
+		if err != nil {
+			return err
+		}
+		info.Fill(a)
+		return nil
+	}
+}
+
+// readAt reads up to n bytes from r at off.
+// It returns the bytes read, and an error
+// if there were fewer than n.
+func readAt(r io.ReaderAt, off int64, n int) ([]byte, error) {
+	b := make([]byte, n)
+	m, err := r.ReadAt(b, off)
+	if m == n {
+		return b, nil
+	}
+	if err == nil || err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	return b[:m], err
+}
+
+// seconds returns the duration of n samples at rate samples per second.
+func seconds(n, rate uint64) time.Duration {
+	return time.Duration(float64(n) / float64(rate) * float64(time.Second))
+}
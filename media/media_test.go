@@ -0,0 +1,358 @@
+package media
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/kr/jsonfeed"
+)
+
+func cat(parts ...[]byte) []byte {
+	return bytes.Join(parts, nil)
+}
+
+func be32(n uint32) []byte { return binary.BigEndian.AppendUint32(nil, n) }
+func be64(n uint64) []byte { return binary.BigEndian.AppendUint64(nil, n) }
+func le16(n uint16) []byte { return binary.LittleEndian.AppendUint16(nil, n) }
+func le32(n uint32) []byte { return binary.LittleEndian.AppendUint32(nil, n) }
+func le64(n uint64) []byte { return binary.LittleEndian.AppendUint64(nil, n) }
+func zeros(n int) []byte   { return make([]byte, n) }
+
+var (
+	mp3Header1 = []byte{0xff, 0xfb, 0x90, 0x00} // MPEG-1, 128 kbit/s, 44.1 kHz, stereo
+	mp3Header2 = []byte{0xff, 0xf3, 0x80, 0xc0} // MPEG-2, 64 kbit/s, 22.05 kHz, mono
+)
+
+func id3(n int) []byte {
+	return cat([]byte("ID3\x04\x00\x00"), []byte{0, 0, byte(n >> 7), byte(n & 0x7f)}, zeros(n))
+}
+
+func box(typ string, body ...[]byte) []byte {
+	b := cat(body...)
+	return cat(be32(uint32(8+len(b))), []byte(typ), b)
+}
+
+func mvhd0(scale, d uint32) []byte {
+	return box("mvhd", zeros(12), be32(scale), be32(d), zeros(80))
+}
+
+func hdlr(typ string) []byte {
+	return box("hdlr", zeros(8), []byte(typ), zeros(12))
+}
+
+func oggPage(serial uint32, granule uint64, payload []byte) []byte {
+	return cat([]byte("OggS\x00\x00"), le64(granule), le32(serial), zeros(8), []byte{1, byte(len(payload))}, payload)
+}
+
+func opusHead(skip uint16) []byte {
+	return cat([]byte("OpusHead\x01\x02"), le16(skip), le32(48000), zeros(3))
+}
+
+func vorbisHead(rate uint32) []byte {
+	return cat([]byte("\x01vorbis"), zeros(4), []byte{1}, le32(rate), zeros(15))
+}
+
+func chunk(id string, body []byte) []byte {
+	b := cat([]byte(id), le32(uint32(len(body))), body)
+	if len(body)%2 == 1 {
+		b = append(b, 0)
+	}
+	return b
+}
+
+func wav(chunks ...[]byte) []byte {
+	return cat([]byte("RIFF"), zeros(4), []byte("WAVE"), cat(chunks...))
+}
+
+var wavFmt = chunk("fmt ", cat(le16(1), le16(2), le32(44100), le32(176400), le16(4), le16(16)))
+
+var okCases = []struct {
+	name string
+	b    []byte
+	typ  string
+	d    time.Duration
+}{
+	{
+		"mp3 cbr",
+		cat(id3(100), mp3Header1, zeros(16000-4), []byte("TAG"), zeros(125)),
+		"audio/mpeg", time.Second,
+	},
+	{
+		"mp3 cbr no id3",
+		cat(mp3Header1, zeros(32000-4)),
+		"audio/mpeg", 2 * time.Second,
+	},
+	{
+		"mp3 xing",
+		cat([]byte("ID3\x04\x00\x10\x00\x00\x00\x00"), zeros(10), zeros(3), mp3Header1, zeros(32), []byte("Xing"), be32(1), be32(441), zeros(400)),
+		"audio/mpeg", 11520 * time.Millisecond,
+	},
+	{
+		"mp3 info mpeg-2 mono",
+		cat(mp3Header2, zeros(9), []byte("Info"), be32(3), be32(2205), zeros(400)),
+		"audio/mpeg", 57600 * time.Millisecond,
+	},
+	{
+		"mp3 vbri",
+		cat(mp3Header1, zeros(32), []byte("VBRI"), zeros(10), be32(441), zeros(400)),
+		"audio/mpeg", 11520 * time.Millisecond,
+	},
+	{
+		"m4a",
+		cat(box("ftyp", []byte("M4A "), zeros(4)), box("moov", mvhd0(1000, 5000), box("trak", box("mdia", hdlr("soun")))), box("free")),
+		"audio/mp4", 5 * time.Second,
+	},
+	{
+		"mp4",
+		cat(
+			box("ftyp", []byte("isom"), zeros(4)),
+			box("moov",
+				box("mvhd", []byte{1}, zeros(19), be32(600), be64(1800), zeros(80)),
+				box("trak", box("tkhd"), box("mdia", hdlr("soun"))),
+				box("trak", box("mdia", hdlr("vide"))),
+			),
+			be32(1), []byte("mdat"), be64(24), zeros(8),
+			be32(0), []byte("free"), zeros(20),
+		),
+		"video/mp4", 3 * time.Second,
+	},
+	{
+		"opus",
+		cat(
+			oggPage(7, 0, opusHead(312)),
+			oggPage(7, 48000+312, zeros(100)),
+			oggPage(7, 3*48000+312, zeros(100)),
+			oggPage(8, 9*48000, zeros(10)), // another stream
+			oggPage(7, 1<<64-1, zeros(10)), // no granule
+		),
+		"audio/ogg", 3 * time.Second,
+	},
+	{
+		"opus short",
+		cat(oggPage(7, 0, opusHead(312)), oggPage(7, 100, zeros(1))),
+		"audio/ogg", 0,
+	},
+	{
+		"vorbis",
+		cat(oggPage(1, 0, vorbisHead(44100)), zeros(70000), oggPage(1, 2*44100, []byte("OggS"))),
+		"audio/ogg", 2 * time.Second,
+	},
+	{
+		"wav",
+		wav(chunk("LIST", []byte("abc")), wavFmt, cat([]byte("data"), le32(1<<32-1), zeros(44100))),
+		"audio/wav", 250 * time.Millisecond,
+	},
+}
+
+func TestInspect(t *testing.T) {
+	for _, test := range okCases {
+		info, err := Inspect(bytes.NewReader(test.b), int64(len(test.b)))
+		if err != nil {
+			t.Errorf("%s: Inspect() = %v, want nil", test.name, err)
+			continue
+		}
+		want := Info{MIMEType: test.typ, Size: int64(len(test.b)), Duration: test.d}
+		if *info != want {
+			t.Errorf("%s: Inspect() = %+v, want %+v", test.name, *info, want)
+		}
+	}
+}
+
+func TestInspectBad(t *testing.T) {
+	cases := []struct {
+		name string
+		b    []byte
+	}{
+		{"empty", nil},
+		{"text", []byte("hello, world")},
+		{"mp3 no frame", cat(id3(10), []byte{0xff, 0xeb, 0x90, 0x00}, []byte{0xff, 0xfb, 0xf0, 0}, []byte{0xff, 0xfb, 0x0c, 0})},
+		{"mp3 layer ii", []byte{0xff, 0xfd, 0x90, 0x00}},
+		{"mp4 no moov", box("ftyp", []byte("isom"))},
+		{"mp4 no mvhd", cat(box("ftyp", []byte("isom")), box("moov"))},
+		{"mp4 bad size", cat(box("ftyp", []byte("isom")), be32(4), []byte("moov"))},
+		{"mp4 big size", cat(box("ftyp", []byte("isom")), be32(100), []byte("moov"))},
+		{"mp4 truncated size", cat(box("ftyp", []byte("isom")), be32(1), []byte("moov"), zeros(4))},
+		{"mp4 bad trak", cat(box("ftyp", []byte("isom")), box("moov", box("trak", be32(100), []byte("mdia"))))},
+		{"mp4 zero scale", cat(box("ftyp", []byte("isom")), box("moov", mvhd0(0, 1)))},
+		{"mp4 short mvhd", cat(box("ftyp", []byte("isom")), box("moov", box("mvhd", zeros(12))))},
+		{"mp4 short mvhd v1", cat(box("ftyp", []byte("isom")), box("moov", box("mvhd", []byte{1}, zeros(22))))},
+		{"ogg short", []byte("OggS")},
+		{"ogg codec", oggPage(1, 0, []byte("FLAC"))},
+		{"ogg zero rate", oggPage(1, 0, vorbisHead(0))},
+		{"ogg no final page", oggPage(1, 1<<64-1, vorbisHead(8000))},
+		{"wav no fmt", wav(chunk("data", zeros(4)))},
+		{"wav short chunk", cat(wav(), []byte("LIST"), le32(2), []byte("ab"), zeros(4))},
+		{"wav short fmt", wav(chunk("fmt ", zeros(4)))},
+	}
+	for _, test := range cases {
+		info, err := Inspect(bytes.NewReader(test.b), int64(len(test.b)))
+		if err == nil {
+			t.Errorf("%s: Inspect() = %+v, want error", test.name, info)
+		}
+	}
+}
+
+// errReaderAt fails to read at or past limit.
+type errReaderAt struct {
+	b     []byte
+	limit int64
+}
+
+func (r errReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	if off >= r.limit {
+		return 0, errors.New("read")
+	}
+	return bytes.NewReader(r.b).ReadAt(p, off)
+}
+
+func TestInspectReadError(t *testing.T) {
+	cases := []errReaderAt{
+		{cat(box("ftyp", []byte("isom")), box("moov")), 12},
+		{wav(wavFmt), 12},
+		{cat(oggPage(1, 0, vorbisHead(8000)), zeros(70000)), 100},
+	}
+	for _, r := range cases {
+		_, err := Inspect(r, int64(len(r.b)))
+		if err == nil {
+			t.Errorf("Inspect(%q) = nil, want error", r.b[:12])
+		}
+	}
+}
+
+func TestInspectFile(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "a.wav")
+	b := okCases[len(okCases)-1].b
+	if err := os.WriteFile(name, b, 0o666); err != nil {
+		t.Fatal(err)
+	}
+	info, err := InspectFile(name)
+	if err != nil || info.Duration != 250*time.Millisecond {
+		t.Errorf("InspectFile() = %+v, %v, want 250ms", info, err)
+	}
+	if _, err := InspectFile(name + ".missing"); err == nil {
+		t.Errorf("InspectFile(missing) = nil, want error")
+	}
+}
+
+func TestFill(t *testing.T) {
+	info := &Info{MIMEType: "audio/mpeg", Size: 1234, Duration: 1500 * time.Millisecond}
+	var a jsonfeed.Attachment
+	info.Fill(&a)
+	want := jsonfeed.Attachment{MIMEType: "audio/mpeg", SizeInBytes: 1234, DurationInSeconds: 1.5}
+	if a != want {
+		t.Errorf("Fill() = %+v, want %+v", a, want)
+	}
+	a = jsonfeed.Attachment{MIMEType: "audio/x-mp3"}
+	info.Fill(&a)
+	if a.MIMEType != "audio/x-mp3" {
+		t.Errorf("Fill() MIMEType = %q, want audio/x-mp3", a.MIMEType)
+	}
+}
+
+// noReaderAtFS hides the ReadAt method of its files.
+type noReaderAtFS struct{ fs.FS }
+
+func (fsys noReaderAtFS) Open(name string) (fs.File, error) {
+	f, err := fsys.FS.Open(name)
+	return struct{ fs.File }{f}, err
+}
+
+// errFS returns files that fail to read or stat.
+type errFS struct{ stat bool }
+
+func (fsys errFS) Open(name string) (fs.File, error) {
+	return errFile(fsys), nil
+}
+
+type errFile struct{ stat bool }
+
+func (f errFile) Stat() (fs.FileInfo, error) {
+	if f.stat {
+		return nil, errors.New("stat")
+	}
+	return fstest.MapFS{"x": {}}.Stat("x")
+}
+func (f errFile) Read([]byte) (int, error) { return 0, errors.New("read") }
+func (f errFile) Close() error             { return nil }
+
+func TestFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"ep 1.mp3":  {Data: okCases[0].b},
+		"ep2.m4a":   {Data: okCases[5].b},
+		"notes.txt": {Data: []byte("hello")},
+	}
+	b := new(jsonfeed.Builder)
+	b.Title("podcast").
+		FillAttachments(FS(fsys, "https://example.org/media/")).
+		AddItem(jsonfeed.Item{ID: "1", ContentText: "one"}).
+		WithAttachment(jsonfeed.Attachment{URL: "https://example.org/media/ep%201.mp3", Title: "ep1"}).
+		WithAttachment(jsonfeed.Attachment{URL: "https://example.org/media/ep2.m4a", MIMEType: "audio/x-m4a"}).
+		WithAttachment(jsonfeed.Attachment{URL: "https://cdn.example/ep1.ogg", MIMEType: "audio/ogg", SizeInBytes: 5})
+	f, err := b.Build()
+	if err != nil {
+		t.Fatalf("Build() = %v, want nil", err)
+	}
+	want := []jsonfeed.Attachment{
+		{URL: "https://example.org/media/ep%201.mp3", Title: "ep1", MIMEType: "audio/mpeg", SizeInBytes: int64(len(okCases[0].b)), DurationInSeconds: 1},
+		{URL: "https://example.org/media/ep2.m4a", MIMEType: "audio/x-m4a", SizeInBytes: int64(len(okCases[5].b)), DurationInSeconds: 5},
+		{URL: "https://cdn.example/ep1.ogg", MIMEType: "audio/ogg", SizeInBytes: 5},
+	}
+	for i, a := range f.Items[0].Attachments {
+		if a != want[i] {
+			t.Errorf("attachment %d = %+v, want %+v", i, a, want[i])
+		}
+	}
+
+	fill := FS(noReaderAtFS{fsys}, "https://example.org/media/")
+	a := jsonfeed.Attachment{URL: "https://example.org/media/ep2.m4a"}
+	if err := fill(&a); err != nil || a.DurationInSeconds != 5 {
+		t.Errorf("fill(%q) = %v, duration %v, want nil, 5", a.URL, err, a.DurationInSeconds)
+	}
+
+	// Without a trailing slash,
+	// the base URL still ends at a path boundary.
+	fill = FS(fsys, "https://example.org/media")
+	for _, u := range []string{"https://example.org/media-archive/old.mp3", "https://example.org/mediaep2.m4a"} {
+		a := jsonfeed.Attachment{URL: u}
+		if err := fill(&a); err != nil || a.MIMEType != "" {
+			t.Errorf("fill(%q) = %v, %+v, want nil and unchanged", u, err, a)
+		}
+	}
+	a = jsonfeed.Attachment{URL: "https://example.org/media/ep2.m4a"}
+	if err := fill(&a); err != nil || a.DurationInSeconds != 5 {
+		t.Errorf("fill(%q) = %v, duration %v, want nil, 5", a.URL, err, a.DurationInSeconds)
+	}
+
+	bad := []struct {
+		fsys fs.FS
+		url  string
+	}{
+		{fsys, "https://example.org/media/notes.txt"},
+		{fsys, "https://example.org/media/missing.mp3"},
+		{fsys, "https://example.org/media/%zz"},
+		{errFS{stat: true}, "https://example.org/media/x"},
+		{noReaderAtFS{errFS{}}, "https://example.org/media/x"},
+	}
+	for _, test := range bad {
+		a := jsonfeed.Attachment{URL: test.url}
+		if err := FS(test.fsys, "https://example.org/media/")(&a); err == nil {
+			t.Errorf("fill(%q) = nil, want error", test.url)
+		}
+	}
+}
+
+func TestReadAt(t *testing.T) {
+	r := strings.NewReader("abc")
+	if b, err := readAt(r, 1, 5); string(b) != "bc" || err != io.ErrUnexpectedEOF {
+		t.Errorf("readAt() = %q, %v, want bc, %v", b, err, io.ErrUnexpectedEOF)
+	}
+}
@@ -0,0 +1,117 @@
+package media
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"time"
+)
+
+var (
+	mp3Bitrates = [2][15]int{ // kbit/s for layer III, by MPEG-1 and MPEG-2 or 2.5
+		{0, 32, 40, 48, 56, 64, 80, 96, 112, 128, 160, 192, 224, 256, 320},
+		{0, 8, 16, 24, 32, 40, 48, 56, 64, 80, 96, 112, 128, 144, 160},
+	}
+	mp3Rates = [4][3]int{ // Hz, by version bits
+		{11025, 12000, 8000},  // MPEG-2.5
+		{},                    // reserved
+		{22050, 24000, 16000}, // MPEG-2
+		{44100, 48000, 32000}, // MPEG-1
+	}
+)
+
+// An mp3Header is a parsed MPEG audio layer III frame header.
+type mp3Header struct {
+	mpeg1   bool
+	mono    bool
+	bitrate int // bits per second
+	rate    int // samples per second
+}
+
+// samples returns the number of samples in each frame.
+func (h *mp3Header) samples() uint64 {
+	if h.mpeg1 {
+		return 1152
+	}
+	return 576
+}
+
+// parseMP3Header parses the 4-byte frame header in b.
+func parseMP3Header(b []byte) (h mp3Header, ok bool) {
+	if b[0] != 0xff || b[1]&0xe0 != 0xe0 {
+		return h, false
+	}
+	version := b[1] >> 3 & 3
+	layer := b[1] >> 1 & 3
+	bri := int(b[2] >> 4)
+	sri := int(b[2] >> 2 & 3)
+	if version == 1 || layer != 1 || bri == 0 || bri == 15 || sri == 3 {
+		return h, false
+	}
+	h.mpeg1 = version == 3
+	h.mono = b[3]>>6 == 3
+	table := 1
+	if h.mpeg1 {
+		table = 0
+	}
+	h.bitrate = mp3Bitrates[table][bri] * 1000
+	h.rate = mp3Rates[version][sri]
+	return h, true
+}
+
+// mp3Duration returns the duration of the MP3 file in r.
+// It skips any ID3v2 tag, then finds the first frame.
+// If that frame holds a Xing, Info, or VBRI header
+// with the number of frames, it uses that;
+// otherwise it assumes every frame has the bit rate of the first.
+func mp3Duration(r io.ReaderAt, size int64) (time.Duration, error) {
+	start := int64(0)
+	if b, err := readAt(r, 0, 10); err == nil && string(b[:3]) == "ID3" {
+		n := int64(b[6]&0x7f)<<21 | int64(b[7]&0x7f)<<14 | int64(b[8]&0x7f)<<7 | int64(b[9]&0x7f)
+		start = 10 + n
+		if b[5]&0x10 != 0 { // footer present
+			start += 10
+		}
+	}
+	buf, _ := readAt(r, start, 64<<10)
+	for i := 0; i+4 <= len(buf); i++ {
+		h, ok := parseMP3Header(buf[i:])
+		if !ok {
+			continue
+		}
+		if n, ok := mp3Frames(buf[i:], &h); ok {
+			return seconds(n*h.samples(), uint64(h.rate)), nil
+		}
+		end := size
+		if tag, err := readAt(r, size-128, 3); err == nil && string(tag) == "TAG" {
+			end -= 128 // ID3v1 tag
+		}
+		bits := float64(end-start-int64(i)) * 8
+		return time.Duration(bits / float64(h.bitrate) * float64(time.Second)), nil
+	}
+	return 0, errors.New("media: no MP3 frame found")
+}
+
+// mp3Frames returns the number of frames in the file
+// from the Xing, Info, or VBRI header in frame,
+// which begins with header h.
+func mp3Frames(frame []byte, h *mp3Header) (uint64, bool) {
+	off := 4 + 17 // after the side information
+	switch {
+	case h.mpeg1 && !h.mono:
+		off = 4 + 32
+	case !h.mpeg1 && h.mono:
+		off = 4 + 9
+	}
+	if len(frame) >= off+12 {
+		tag := string(frame[off : off+4])
+		flags := binary.BigEndian.Uint32(frame[off+4:])
+		if (tag == "Xing" || tag == "Info") && flags&1 != 0 {
+			return uint64(binary.BigEndian.Uint32(frame[off+8:])), true
+		}
+	}
+	if len(frame) >= 36+18 && string(frame[36:40]) == "VBRI" {
+		return uint64(binary.BigEndian.Uint32(frame[36+14:])), true
+	}
+	return 0, false
+}
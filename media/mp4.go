@@ -0,0 +1,126 @@
+package media
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"time"
+)
+
+// mp4Info returns the MIME type and duration of the MP4 file in r.
+// The duration comes from the movie header (mvhd) box.
+// The file is video/mp4 if any track has a video handler,
+// or else audio/mp4.
+func mp4Info(r io.ReaderAt, size int64) (string, time.Duration, error) {
+	var (
+		d      time.Duration
+		found  bool
+		video  bool
+		failed error
+	)
+	err := eachBox(r, 0, size, func(typ string, body, end int64) error {
+		if typ != "moov" {
+			return nil
+		}
+		return eachBox(r, body, end, func(typ string, body, end int64) error {
+			switch typ {
+			case "mvhd":
+				d, failed = mvhdDuration(r, body)
+				found = true
+			case "trak":
+				v, err := isVideoTrack(r, body, end)
+				if err != nil {
+					return err
+				}
+				video = video || v
+			}
+			return nil
+		})
+	})
+	switch {
+	case err != nil:
+		return "", 0, err
+	case failed != nil:
+		return "", 0, failed
+	case !found:
+		return "", 0, errors.New("media: no movie header in MP4 file")
+	}
+	if video {
+		return "video/mp4", d, nil
+	}
+	return "audio/mp4", d, nil
+}
+
+// mvhdDuration returns the duration in the mvhd box
+// whose body begins at off.
+func mvhdDuration(r io.ReaderAt, off int64) (time.Duration, error) {
+	b, err := readAt(r, off, 32)
+	if err != nil && len(b) < 20 {
+		return 0, errors.New("media: short movie header in MP4 file")
+	}
+	var scale, n uint64
+	if b[0] == 1 { // version 1 has 64-bit times
+		if len(b) < 32 {
+			return 0, errors.New("media: short movie header in MP4 file")
+		}
+		scale = uint64(binary.BigEndian.Uint32(b[20:]))
+		n = binary.BigEndian.Uint64(b[24:])
+	} else {
+		scale = uint64(binary.BigEndian.Uint32(b[12:]))
+		n = uint64(binary.BigEndian.Uint32(b[16:]))
+	}
+	if scale == 0 {
+		return 0, errors.New("media: zero time scale in MP4 file")
+	}
+	return seconds(n, scale), nil
+}
+
+// isVideoTrack reports whether the trak box
+// whose body is r[off:end] has a video handler.
+func isVideoTrack(r io.ReaderAt, off, end int64) (bool, error) {
+	video := false
+	err := eachBox(r, off, end, func(typ string, body, end int64) error {
+		if typ != "mdia" {
+			return nil
+		}
+		return eachBox(r, body, end, func(typ string, body, end int64) error {
+			if typ == "hdlr" {
+				b, _ := readAt(r, body+8, 4)
+				video = video || string(b) == "vide"
+			}
+			return nil
+		})
+	})
+	return video, err
+}
+
+// eachBox calls fn for each box in r[off:end],
+// with the box's type and the offsets of its body.
+func eachBox(r io.ReaderAt, off, end int64, fn func(typ string, body, end int64) error) error {
+	for off+8 <= end {
+		b, err := readAt(r, off, 16)
+		if len(b) < 8 {
+			return err
+		}
+		size := int64(binary.BigEndian.Uint32(b))
+		body := off + 8
+		switch size {
+		case 0: // box extends to the end
+			size = end - off
+		case 1: // 64-bit size follows the type
+			if len(b) < 16 {
+				return errors.New("media: truncated box in MP4 file")
+			}
+			size = int64(binary.BigEndian.Uint64(b[8:]))
+			body += 8
+		}
+		if size < body-off || off+size > end {
+			return errors.New("media: bad box size in MP4 file")
+		}
+		if err := fn(string(b[4:8]), body, off+size); err != nil {
+			return err
+		}
+		off += size
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+package media
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"time"
+)
+
+// oggDuration returns the duration of the Ogg Opus or Ogg Vorbis
+// file in r.
+// It reads the codec's sample rate from the first page
+// and the granule position, a sample count,
+// from the last page of the same logical stream.
+func oggDuration(r io.ReaderAt, size int64) (time.Duration, error) {
+	page, err := readAt(r, 0, 27)
+	if len(page) < 27 {
+		return 0, err
+	}
+	serial := binary.LittleEndian.Uint32(page[14:])
+	first := 27 + int(page[26]) // after the segment table
+	packet, _ := readAt(r, int64(first), 19)
+	var rate, skip uint64
+	switch {
+	case len(packet) >= 19 && string(packet[:8]) == "OpusHead":
+		rate = 48000 // granule positions are always at 48 kHz
+		skip = uint64(binary.LittleEndian.Uint16(packet[10:]))
+	case len(packet) >= 16 && string(packet[:7]) == "\x01vorbis":
+		rate = uint64(binary.LittleEndian.Uint32(packet[12:]))
+	default:
+		return 0, errors.New("media: unknown codec in Ogg file")
+	}
+	if rate == 0 {
+		return 0, errors.New("media: zero sample rate in Ogg file")
+	}
+
+	// A page is at most 27+255+255*255 bytes long,
+	// so the last page starts within that many bytes of the end.
+	const maxPage = 27 + 255 + 255*255
+	off := max(size-maxPage, 0)
+	tail, err := readAt(r, off, int(size-off))
+	if err != nil {
+		return 0, err
+	}
+	for i := len(tail); ; {
+		i = bytes.LastIndex(tail[:i], []byte("OggS"))
+		if i < 0 {
+			return 0, errors.New("media: no final page in Ogg file")
+		}
+		p := tail[i:]
+		if len(p) < 27 || p[4] != 0 || binary.LittleEndian.Uint32(p[14:]) != serial {
+			continue
+		}
+		granule := binary.LittleEndian.Uint64(p[6:])
+		if granule == 1<<64-1 { // no packet ends on this page
+			continue
+		}
+		if granule < skip {
+			return 0, nil
+		}
+		return seconds(granule-skip, rate), nil
+	}
+}
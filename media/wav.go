@@ -0,0 +1,41 @@
+package media
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"time"
+)
+
+// wavDuration returns the duration of the WAV file in r,
+// the size of its data chunk divided by the byte rate
+// in its format chunk.
+func wavDuration(r io.ReaderAt, size int64) (time.Duration, error) {
+	var byteRate, data uint64
+	off := int64(12) // after "RIFF", the size, and "WAVE"
+	for off+8 <= size && (byteRate == 0 || data == 0) {
+		b, err := readAt(r, off, 8)
+		if err != nil {
+			return 0, err
+		}
+		n := int64(binary.LittleEndian.Uint32(b[4:]))
+		body := off + 8
+		switch string(b[:4]) {
+		case "fmt ":
+			f, err := readAt(r, body, 12)
+			if err != nil {
+				return 0, err
+			}
+			byteRate = uint64(binary.LittleEndian.Uint32(f[8:]))
+		case "data":
+			// Streaming writers may leave the size unset,
+			// so don't trust it past the end of the file.
+			data = uint64(min(n, size-body))
+		}
+		off = body + n + n&1 // chunks are padded to an even size
+	}
+	if byteRate == 0 {
+		return 0, errors.New("media: no format chunk in WAV file")
+	}
+	return seconds(data, byteRate), nil
+}
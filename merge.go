@@ -0,0 +1,161 @@
+package jsonfeed
+
+import (
+	"encoding/json"
+	"sort"
+	"time"
+)
+
+// SourceExtension is the name of the extension
+// in which Merge records where each item came from.
+// Its value is a JSON object with the source feed's
+// title, feed_url, and home_page_url,
+// and the item's original id.
+const SourceExtension = "_source"
+
+// Source is the value of the SourceExtension extension.
+type Source struct {
+	Title       string `json:"title,omitempty"`
+	FeedURL     string `json:"feed_url,omitempty"`
+	HomePageURL string `json:"home_page_url,omitempty"`
+	ID          string `json:"id"`
+}
+
+// MergeOptions configures Merge.
+type MergeOptions struct {
+	// Title, HomePageURL, FeedURL, and Description
+	// are copied into the merged feed. Title is required.
+	Title       string
+	HomePageURL string
+	FeedURL     string
+	Description string
+
+	// Limit, if positive, is the maximum number of items
+	// in the merged feed. The newest items are kept.
+	Limit int
+
+	// ID, if set, returns the ID to use in the merged feed
+	// for item t from feed src.
+	// By default, the ID is the source's FeedURL,
+	// or its HomePageURL or Title if it has no FeedURL,
+	// then "#", then the original ID.
+	// Either way, the ID depends only on the item and its feed,
+	// so it stays the same from one merge to the next.
+	ID func(src *Feed, t *Item) string
+}
+
+// Merge combines the items from feeds into a single new feed,
+// sorted newest first by DatePublished,
+// or DateModified for items with no DatePublished.
+// The feeds themselves are not modified.
+//
+// Each merged item gets an ID from o.ID,
+// an Author from its source feed if it has none of its own,
+// and a SourceExtension extension describing its source.
+// If two items end up with the same ID,
+// as happens when a feed is passed to Merge twice,
+// only the one most recently modified is kept.
+//
+// Merge returns an error if the merged feed is not valid.
+func Merge(o *MergeOptions, feeds ...*Feed) (*Feed, error) {
+	id := o.ID
+	if id == nil {
+		id = namespacedID
+	}
+	f := &Feed{
+		Version:     Version,
+		Title:       o.Title,
+		HomePageURL: o.HomePageURL,
+		FeedURL:     o.FeedURL,
+		Description: o.Description,
+	}
+	index := make(map[string]int) // ID to index in f.Items
+	for _, src := range feeds {
+		for i := range src.Items {
+			t := copyItem(&src.Items[i])
+			t.ID = id(src, &src.Items[i])
+			if t.Author == nil && src.Author != nil {
+				a := *src.Author
+				t.Author = &a
+			}
+			ext, _ := json.Marshal(&Source{
+				Title:       src.Title,
+				FeedURL:     src.FeedURL,
+				HomePageURL: src.HomePageURL,
+				ID:          src.Items[i].ID,
+			})
+			if t.Extensions == nil {
+				t.Extensions = make(map[string]json.RawMessage)
+			}
+			t.Extensions[SourceExtension] = ext
+			if j, ok := index[t.ID]; ok {
+				if t.DateModified.After(f.Items[j].DateModified) {
+					f.Items[j] = t
+				}
+				continue
+			}
+			index[t.ID] = len(f.Items)
+			f.Items = append(f.Items, t)
+		}
+	}
+	sortNewest(f.Items)
+	if o.Limit > 0 && len(f.Items) > o.Limit {
+		f.Items = f.Items[:o.Limit]
+	}
+	if err := validFeed(f); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+func namespacedID(src *Feed, t *Item) string {
+	ns := src.FeedURL
+	if ns == "" {
+		ns = src.HomePageURL
+	}
+	if ns == "" {
+		ns = src.Title
+	}
+	return ns + "#" + t.ID
+}
+
+// copyItem returns a copy of t
+// that shares no slices or maps with it.
+func copyItem(t *Item) Item {
+	c := *t
+	if t.Author != nil {
+		a := *t.Author
+		c.Author = &a
+	}
+	c.RawID = append(json.RawMessage(nil), t.RawID...)
+	c.Tags = append([]string(nil), t.Tags...)
+	c.Attachments = append([]Attachment(nil), t.Attachments...)
+	if t.Extensions != nil {
+		c.Extensions = make(map[string]json.RawMessage, len(t.Extensions))
+		for k, v := range t.Extensions {
+			c.Extensions[k] = v
+		}
+	}
+	return c
+}
+
+// itemDate returns the date to use when ordering t:
+// its DatePublished, or its DateModified if that is zero.
+func itemDate(t *Item) time.Time {
+	if t.DatePublished.IsZero() {
+		return t.DateModified
+	}
+	return t.DatePublished
+}
+
+// sortNewest sorts items by itemDate, newest first.
+// Items with the same date are ordered by ID.
+func sortNewest(items []Item) {
+	sort.SliceStable(items, func(i, j int) bool {
+		a, b := itemDate(&items[i]), itemDate(&items[j])
+		if !a.Equal(b) {
+			return a.After(b)
+		}
+		return items[i].ID < items[j].ID
+	})
+}
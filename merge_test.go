@@ -0,0 +1,125 @@
+package jsonfeed
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestMerge(t *testing.T) {
+	d := func(day int) time.Time { return time.Date(2017, 5, day, 0, 0, 0, 0, time.UTC) }
+	a := &Feed{
+		Title:   "A",
+		FeedURL: "https://a.example/feed.json",
+		Author:  &Author{Name: "a"},
+		Items: []Item{
+			{ID: "1", ContentText: "a1", DatePublished: d(1)},
+			{ID: "2", ContentText: "a2", DatePublished: d(3), Author: &Author{Name: "guest"}},
+		},
+	}
+	b := &Feed{
+		Title: "B",
+		Items: []Item{
+			{ID: "1", ContentText: "b1", DateModified: d(2), Tags: []string{"t"}},
+		},
+	}
+	c := &Feed{
+		Title:       "C",
+		HomePageURL: "https://c.example/",
+		Items: []Item{
+			{ID: "1", ContentText: "c1", DatePublished: d(2)},
+		},
+	}
+	saved := copyItem(&a.Items[0])
+	got, err := Merge(&MergeOptions{Title: "Planet", FeedURL: "https://planet.example/feed.json"}, a, b, c)
+	if err != nil {
+		t.Fatalf("Merge() = %v, want nil", err)
+	}
+	src := func(s *Source) map[string]json.RawMessage {
+		b, _ := json.Marshal(s)
+		return map[string]json.RawMessage{SourceExtension: b}
+	}
+	want := &Feed{
+		Version: Version,
+		Title:   "Planet",
+		FeedURL: "https://planet.example/feed.json",
+		Items: []Item{
+			{
+				ID:            "https://a.example/feed.json#2",
+				ContentText:   "a2",
+				DatePublished: d(3),
+				Author:        &Author{Name: "guest"},
+				Extensions:    src(&Source{Title: "A", FeedURL: "https://a.example/feed.json", ID: "2"}),
+			},
+			{
+				ID:           "B#1",
+				ContentText:  "b1",
+				DateModified: d(2),
+				Tags:         []string{"t"},
+				Extensions:   src(&Source{Title: "B", ID: "1"}),
+			},
+			{
+				ID:            "https://c.example/#1",
+				ContentText:   "c1",
+				DatePublished: d(2),
+				Extensions:    src(&Source{Title: "C", HomePageURL: "https://c.example/", ID: "1"}),
+			},
+			{
+				ID:            "https://a.example/feed.json#1",
+				ContentText:   "a1",
+				DatePublished: d(1),
+				Author:        &Author{Name: "a"},
+				Extensions:    src(&Source{Title: "A", FeedURL: "https://a.example/feed.json", ID: "1"}),
+			},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Merge() = %+v, want %+v", got, want)
+	}
+	if !reflect.DeepEqual(a.Items[0], saved) {
+		t.Errorf("Merge() modified its input")
+	}
+}
+
+func TestMergeOptions(t *testing.T) {
+	old := &Feed{Title: "F", Items: []Item{
+		{ID: "1", ContentText: "old", DateModified: time.Unix(1, 0)},
+		{ID: "2", ContentText: "two", DatePublished: time.Unix(5, 0)},
+	}}
+	cur := &Feed{Title: "F", Items: []Item{
+		{ID: "1", ContentText: "new", DateModified: time.Unix(2, 0), Extensions: map[string]json.RawMessage{"_x": []byte("1")}},
+		{ID: "2", ContentText: "stale", DatePublished: time.Unix(5, 0)},
+	}}
+	o := &MergeOptions{
+		Title: "Merged",
+		Limit: 1,
+		ID:    func(src *Feed, t *Item) string { return t.ID },
+	}
+	got, err := Merge(o, old, cur)
+	if err != nil {
+		t.Fatalf("Merge() = %v, want nil", err)
+	}
+	if len(got.Items) != 1 || got.Items[0].ContentText != "two" {
+		t.Errorf("Merge() items = %+v, want only item 2", got.Items)
+	}
+	o.Limit = 0
+	got, _ = Merge(o, old, cur)
+	if len(got.Items) != 2 || got.Items[1].ContentText != "new" || string(got.Items[1].Extensions["_x"]) != "1" {
+		t.Errorf("Merge() items = %+v, want newer item 1", got.Items)
+	}
+}
+
+func TestMergeInvalid(t *testing.T) {
+	f := &Feed{Title: "F", Items: []Item{{ID: "1"}}} // no content
+	cases := []*MergeOptions{
+		{},           // no title
+		{Title: "T"}, // invalid item
+	}
+	for _, o := range cases {
+		_, err := Merge(o, f)
+		if err == nil {
+			t.Errorf("Merge(%+v) = nil, want error", o)
+		}
+	}
+}
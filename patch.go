@@ -0,0 +1,335 @@
+package jsonfeed
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"time"
+)
+
+// A Patch describes a set of changes to a feed.
+// It is meant to be sent in place of a whole new feed
+// when only a little has changed,
+// and it can be marshaled and unmarshaled with package encoding/json.
+//
+// Apply a patch with Apply; make one with MakePatch.
+type Patch struct {
+	// Meta is a JSON Merge Patch, as described in RFC 7396,
+	// for the feed-level fields. It must not change the items.
+	Meta json.RawMessage `json:"meta,omitempty"`
+
+	// Ops lists changes to individual items, in order.
+	Ops []PatchOp `json:"ops,omitempty"`
+
+	// Order, if not empty, lists the IDs of all items
+	// in the patched feed, in order.
+	// Otherwise, existing items keep their order,
+	// and added items go at the end.
+	Order []string `json:"order,omitempty"`
+}
+
+// Patch operations.
+const (
+	OpAdd     = "add"     // add Item, which must have a new ID
+	OpRemove  = "remove"  // remove the item with ID
+	OpReplace = "replace" // replace the item with ID by Item
+	OpMerge   = "merge"   // apply Item as a JSON Merge Patch to the item with ID
+)
+
+// A PatchOp is a change to one item.
+type PatchOp struct {
+	Op   string          `json:"op"`
+	ID   string          `json:"id"`
+	Item json.RawMessage `json:"item,omitempty"`
+}
+
+// Apply returns the result of applying p to f.
+// F itself is not modified.
+// Apply returns an error if an operation refers to a missing item,
+// if it would add an item with an ID already in use,
+// or if the result is not a valid feed,
+// for example because an item has neither content field.
+func Apply(f *Feed, p *Patch) (*Feed, error) {
+	out := new(Feed)
+	*out = *f
+	if len(p.Meta) > 0 {
+		var err error
+		out, err = applyMeta(f, p.Meta)
+		if err != nil {
+			return nil, err
+		}
+	}
+	out.Items = make([]Item, len(f.Items))
+	for i := range f.Items {
+		out.Items[i] = copyItem(&f.Items[i])
+	}
+	index := func(id string) int {
+		for i := range out.Items {
+			if out.Items[i].ID == id {
+				return i
+			}
+		}
+		return -1
+	}
+	for _, op := range p.Ops {
+		i := index(op.ID)
+		if op.Op != OpAdd && i < 0 {
+			return nil, errors.New("jsonfeed: patch: " + op.Op + " of missing item " + op.ID)
+		}
+		switch op.Op {
+		case OpAdd:
+			if i >= 0 {
+				return nil, errors.New("jsonfeed: patch: duplicate id " + op.ID)
+			}
+			t, err := patchItem(op, op.Item, &Item{})
+			if err != nil {
+				return nil, err
+			}
+			out.Items = append(out.Items, t)
+		case OpRemove:
+			out.Items = append(out.Items[:i], out.Items[i+1:]...)
+		case OpReplace:
+			t, err := patchItem(op, op.Item, &out.Items[i])
+			if err != nil {
+				return nil, err
+			}
+			out.Items[i] = t
+		case OpMerge:
+			cur, err := json.Marshal(&out.Items[i])
+			if err != nil {
+				return nil, err
+			}
+			b, err := mergePatch(cur, op.Item)
+			if err != nil {
+				return nil, err
+			}
+			t, err := patchItem(op, b, &out.Items[i])
+			if err != nil {
+				return nil, err
+			}
+			out.Items[i] = t
+		default:
+			return nil, errors.New("jsonfeed: patch: unknown op " + strconv.Quote(op.Op))
+		}
+	}
+	if len(p.Order) > 0 {
+		items, err := reorder(out.Items, p.Order)
+		if err != nil {
+			return nil, err
+		}
+		out.Items = items
+	}
+	if err := validFeed(out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// patchItem decodes the item in b for op.
+// The item's ID must be op.ID.
+// Dates missing or zero in b are taken from src,
+// not filled in with the current time as by UnmarshalJSON,
+// so that applying a patch gives the same result every time.
+func patchItem(op PatchOp, b []byte, src *Item) (Item, error) {
+	var t Item
+	if err := json.Unmarshal(b, &t); err != nil {
+		return Item{}, err
+	}
+	var dates struct {
+		Published *time.Time `json:"date_published"`
+		Modified  *time.Time `json:"date_modified"`
+	}
+	json.Unmarshal(b, &dates) // already known to be valid
+	if dates.Published == nil || dates.Published.IsZero() {
+		t.DatePublished = src.DatePublished
+	}
+	if dates.Modified == nil || dates.Modified.IsZero() {
+		t.DateModified = src.DateModified
+	}
+	if t.ID != op.ID {
+		return Item{}, errors.New("jsonfeed: patch: " + op.Op + " of item " + op.ID + " has id " + t.ID)
+	}
+	return t, nil
+}
+
+// applyMeta returns a shallow copy of f
+// with the merge patch in patch applied to its feed-level fields.
+func applyMeta(f *Feed, patch json.RawMessage) (*Feed, error) {
+	type T Feed // get rid of methods to avoid validation
+	meta := *f
+	meta.Items = nil
+	cur, _ := json.Marshal((*T)(&meta)) // feed-level fields always encode
+	b, err := mergePatch(cur, patch)
+	if err != nil {
+		return nil, err
+	}
+	out := new(Feed)
+	if err := json.Unmarshal(b, (*T)(out)); err != nil {
+		return nil, err
+	}
+	if out.Items != nil {
+		return nil, errors.New("jsonfeed: patch: meta changes items")
+	}
+	return out, nil
+}
+
+// reorder returns items in the order given by ids,
+// which must be a permutation of their IDs.
+func reorder(items []Item, ids []string) ([]Item, error) {
+	if len(ids) != len(items) {
+		return nil, errors.New("jsonfeed: patch: order has wrong number of items")
+	}
+	byID := make(map[string]*Item, len(items))
+	for i := range items {
+		byID[items[i].ID] = &items[i]
+	}
+	out := make([]Item, 0, len(items))
+	for _, id := range ids {
+		t, ok := byID[id]
+		if !ok {
+			return nil, errors.New("jsonfeed: patch: order has missing or repeated item " + id)
+		}
+		delete(byID, id)
+		out = append(out, *t)
+	}
+	return out, nil
+}
+
+// MakePatch returns a patch that changes old into new.
+// Items that changed are sent as merge operations
+// containing only their changed fields.
+func MakePatch(old, new *Feed) (*Patch, error) {
+	type T Feed // get rid of methods to avoid validation
+	oldMeta, newMeta := *old, *new
+	oldMeta.Items, newMeta.Items = nil, nil
+	a, _ := json.Marshal((*T)(&oldMeta)) // feed-level fields always encode
+	b, _ := json.Marshal((*T)(&newMeta))
+	p := &Patch{Meta: mergeDiff(a, b)}
+
+	d := Diff(old, new)
+	var order []string // expected order after applying ops
+	removed := make(map[string]bool)
+	for _, t := range d.Removed {
+		removed[t.ID] = true
+		p.Ops = append(p.Ops, PatchOp{Op: OpRemove, ID: t.ID})
+	}
+	for _, t := range old.Items {
+		if !removed[t.ID] {
+			order = append(order, t.ID)
+		}
+	}
+	oldItems := make(map[string]*Item, len(old.Items))
+	for i := range old.Items {
+		oldItems[old.Items[i].ID] = &old.Items[i]
+	}
+	newItems := make(map[string]*Item, len(new.Items))
+	for i := range new.Items {
+		newItems[new.Items[i].ID] = &new.Items[i]
+	}
+	for _, m := range d.Modified {
+		a, err := json.Marshal(oldItems[m.ID])
+		if err != nil {
+			return nil, err
+		}
+		b, err := json.Marshal(newItems[m.ID])
+		if err != nil {
+			return nil, err
+		}
+		if mp := mergeDiff(a, b); mp != nil {
+			p.Ops = append(p.Ops, PatchOp{Op: OpMerge, ID: m.ID, Item: mp})
+		}
+	}
+	for i := range d.Added {
+		b, err := json.Marshal(&d.Added[i])
+		if err != nil {
+			return nil, err
+		}
+		p.Ops = append(p.Ops, PatchOp{Op: OpAdd, ID: d.Added[i].ID, Item: b})
+		order = append(order, d.Added[i].ID)
+	}
+	for i := range new.Items {
+		if i >= len(order) || new.Items[i].ID != order[i] {
+			for _, t := range new.Items {
+				p.Order = append(p.Order, t.ID)
+			}
+			break
+		}
+	}
+	return p, nil
+}
+
+// mergePatch applies the JSON Merge Patch patch to doc,
+// as described in RFC 7396.
+// Doc must be valid JSON.
+func mergePatch(doc, patch []byte) ([]byte, error) {
+	var d, p interface{}
+	decodeNumber(doc, &d)
+	if err := decodeNumber(patch, &p); err != nil {
+		return nil, err
+	}
+	b, _ := json.Marshal(mergeValue(d, p)) // decoded values always encode
+	return b, nil
+}
+
+func mergeValue(doc, patch interface{}) interface{} {
+	pm, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+	dm, ok := doc.(map[string]interface{})
+	if !ok {
+		dm = make(map[string]interface{})
+	}
+	for k, v := range pm {
+		if v == nil {
+			delete(dm, k)
+		} else {
+			dm[k] = mergeValue(dm[k], v)
+		}
+	}
+	return dm
+}
+
+// mergeDiff returns a JSON Merge Patch that changes
+// the JSON object a into the JSON object b,
+// or nil if they are the same.
+// Only top-level fields are compared;
+// a changed field is sent in full.
+// Both a and b must be valid JSON objects.
+func mergeDiff(a, b []byte) json.RawMessage {
+	var am, bm map[string]json.RawMessage
+	json.Unmarshal(a, &am)
+	json.Unmarshal(b, &bm)
+	patch := make(map[string]json.RawMessage)
+	for k := range am {
+		if _, ok := bm[k]; !ok {
+			patch[k] = json.RawMessage("null")
+		}
+	}
+	for k, v := range bm {
+		if !bytes.Equal(am[k], v) {
+			patch[k] = v
+		}
+	}
+	if len(patch) == 0 {
+		return nil
+	}
+	out, _ := json.Marshal(patch)
+	return out
+}
+
+// decodeNumber is json.Unmarshal,
+// but it keeps numbers as json.Number
+// so they are not changed by a round trip.
+func decodeNumber(b []byte, v interface{}) error {
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	if err := dec.Decode(v); err != nil {
+		return err
+	}
+	if dec.More() {
+		return errors.New("jsonfeed: patch: extra data after JSON value")
+	}
+	return nil
+}
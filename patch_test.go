@@ -0,0 +1,221 @@
+package jsonfeed
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestMakePatchApply(t *testing.T) {
+	d := time.Date(2017, 5, 17, 0, 0, 0, 0, time.UTC)
+	item := func(id, text string) Item {
+		return Item{ID: id, ContentText: text, DatePublished: d, DateModified: d}
+	}
+	old := &Feed{
+		Version:     Version,
+		Title:       "old",
+		Description: "gone soon",
+		Items:       []Item{item("a", "a"), item("b", "b"), item("c", "c")},
+	}
+	new := &Feed{
+		Version: Version,
+		Title:   "new",
+		Author:  &Author{Name: "kr"},
+		Items:   []Item{item("d", "d"), item("c", "c2"), item("a", "a")},
+	}
+	new.Items[1].Tags = []string{"t"}
+
+	p, err := MakePatch(old, new)
+	if err != nil {
+		t.Fatalf("MakePatch() = %v, want nil", err)
+	}
+	var meta map[string]interface{}
+	json.Unmarshal(p.Meta, &meta)
+	wantMeta := map[string]interface{}{
+		"title":       "new",
+		"description": nil,
+		"author":      map[string]interface{}{"name": "kr"},
+	}
+	if !reflect.DeepEqual(meta, wantMeta) {
+		t.Errorf("MakePatch() meta = %s, want %v", p.Meta, wantMeta)
+	}
+	if n := len(p.Ops); n != 3 {
+		t.Errorf("MakePatch() has %d ops, want 3: %+v", n, p.Ops)
+	}
+	if want := []string{"d", "c", "a"}; !reflect.DeepEqual(p.Order, want) {
+		t.Errorf("MakePatch() order = %q, want %q", p.Order, want)
+	}
+
+	// Round trip the patch through JSON, as it would be sent.
+	b, err := json.Marshal(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p = &Patch{}
+	if err := json.Unmarshal(b, p); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := Apply(old, p)
+	if err != nil {
+		t.Fatalf("Apply() = %v, want nil", err)
+	}
+	if d := Diff(got, new); !d.Empty() {
+		t.Errorf("Apply(old, MakePatch(old, new)) differs from new: %+v", d)
+	}
+	if old.Title != "old" || len(old.Items) != 3 || old.Items[2].ContentText != "c" {
+		t.Errorf("Apply() modified its input: %+v", old)
+	}
+}
+
+func TestMakePatchSameOrder(t *testing.T) {
+	old := &Feed{Title: "t", Items: []Item{{ID: "a", ContentText: "a"}}}
+	new := &Feed{Title: "t", Items: []Item{{ID: "a", ContentText: "a"}, {ID: "b", ContentText: "b"}}}
+	p, err := MakePatch(old, new)
+	if err != nil {
+		t.Fatalf("MakePatch() = %v, want nil", err)
+	}
+	if p.Meta != nil || p.Order != nil || len(p.Ops) != 1 || p.Ops[0].Op != OpAdd {
+		t.Errorf("MakePatch() = %+v, want one add", p)
+	}
+}
+
+func TestMakePatchBad(t *testing.T) {
+	bad := map[string]json.RawMessage{"x": json.RawMessage(`1`)} // invalid extension name
+	cases := []struct{ old, new *Feed }{
+		{
+			&Feed{Items: []Item{{ID: "a", ContentText: "a", Extensions: bad}}},
+			&Feed{Items: []Item{{ID: "a", ContentText: "b"}}},
+		},
+		{
+			&Feed{Items: []Item{{ID: "a", ContentText: "a"}}},
+			&Feed{Items: []Item{{ID: "a", ContentText: "b", Extensions: bad}}},
+		},
+		{
+			&Feed{},
+			&Feed{Items: []Item{{ID: "a", ContentText: "b", Extensions: bad}}},
+		},
+	}
+	for _, test := range cases {
+		_, err := MakePatch(test.old, test.new)
+		if err == nil {
+			t.Errorf("MakePatch(%+v, %+v) = nil, want error", test.old, test.new)
+		}
+	}
+}
+
+func TestApply(t *testing.T) {
+	f := &Feed{
+		Version: Version,
+		Title:   "t",
+		Items:   []Item{{ID: "a", ContentText: "a", Title: "title"}},
+	}
+	p := &Patch{Ops: []PatchOp{
+		{Op: OpReplace, ID: "a", Item: json.RawMessage(`{"id":"a","content_text":"new"}`)},
+		{Op: OpMerge, ID: "a", Item: json.RawMessage(`{"summary":"s","_ext":{"n":1.50}}`)},
+		{Op: OpAdd, ID: "b", Item: json.RawMessage(`{"id":"b","content_html":"<p>b</p>"}`)},
+		{Op: OpRemove, ID: "b"},
+	}}
+	got, err := Apply(f, p)
+	if err != nil {
+		t.Fatalf("Apply() = %v, want nil", err)
+	}
+	if len(got.Items) != 1 {
+		t.Fatalf("Apply() items = %+v, want 1 item", got.Items)
+	}
+	g := got.Items[0]
+	if g.Title != "" || g.ContentText != "new" || g.Summary != "s" || string(g.Extensions["_ext"]) != `{"n":1.50}` {
+		t.Errorf("Apply() item = %+v", g)
+	}
+}
+
+func TestApplyDates(t *testing.T) {
+	d := time.Date(2017, 5, 17, 0, 0, 0, 0, time.UTC)
+	f := &Feed{
+		Version: Version,
+		Title:   "t",
+		Items: []Item{
+			{ID: "a", ContentText: "a"},
+			{ID: "b", ContentText: "b", DatePublished: d},
+		},
+	}
+	p := &Patch{Ops: []PatchOp{
+		{Op: OpReplace, ID: "a", Item: json.RawMessage(`{"id":"a","content_text":"a2","date_modified":null}`)},
+		{Op: OpMerge, ID: "b", Item: json.RawMessage(`{"summary":"s"}`)},
+		{Op: OpAdd, ID: "c", Item: json.RawMessage(`{"id":"c","content_text":"c"}`)},
+	}}
+	got, err := Apply(f, p)
+	if err != nil {
+		t.Fatalf("Apply() = %v, want nil", err)
+	}
+	again, _ := Apply(f, p)
+	if !reflect.DeepEqual(got, again) {
+		t.Errorf("Apply() twice = %+v and %+v, want the same", got, again)
+	}
+	want := []time.Time{{}, {}, d, {}, {}, {}}
+	var dates []time.Time
+	for _, t := range got.Items {
+		dates = append(dates, t.DatePublished, t.DateModified)
+	}
+	if !reflect.DeepEqual(dates, want) {
+		t.Errorf("Apply() dates = %v, want %v", dates, want)
+	}
+
+	// A round trip through MakePatch keeps missing dates missing.
+	new := f.Filter(nil)
+	new.Items[0].ContentText = "a3"
+	mp, err := MakePatch(f, new)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err = Apply(f, mp)
+	if err != nil {
+		t.Fatalf("Apply() = %v, want nil", err)
+	}
+	if !reflect.DeepEqual(got.Items, new.Items) {
+		t.Errorf("Apply(f, MakePatch(f, new)) items = %+v, want %+v", got.Items, new.Items)
+	}
+}
+
+func TestApplyBad(t *testing.T) {
+	f := &Feed{
+		Version: Version,
+		Title:   "t",
+		Items:   []Item{{ID: "a", ContentText: "a"}, {ID: "b", ContentText: "b"}},
+	}
+	badExt := &Feed{
+		Version: Version,
+		Title:   "t",
+		Items:   []Item{{ID: "a", ContentText: "a", Extensions: map[string]json.RawMessage{"x": nil}}},
+	}
+	cases := []struct {
+		f *Feed
+		p *Patch
+	}{
+		{f, &Patch{Meta: json.RawMessage(`{`)}},
+		{f, &Patch{Meta: json.RawMessage(`{} {}`)}},
+		{f, &Patch{Meta: json.RawMessage(`{"title":1}`)}},
+		{f, &Patch{Meta: json.RawMessage(`{"items":[]}`)}},
+		{f, &Patch{Meta: json.RawMessage(`{"title":null}`)}}, // invalid result
+		{f, &Patch{Ops: []PatchOp{{Op: OpRemove, ID: "x"}}}},
+		{f, &Patch{Ops: []PatchOp{{Op: OpAdd, ID: "a", Item: json.RawMessage(`{"id":"a","content_text":"x"}`)}}}},
+		{f, &Patch{Ops: []PatchOp{{Op: OpAdd, ID: "c", Item: json.RawMessage(`{`)}}}},
+		{f, &Patch{Ops: []PatchOp{{Op: OpAdd, ID: "c", Item: json.RawMessage(`{"id":"d","content_text":"x"}`)}}}},
+		{f, &Patch{Ops: []PatchOp{{Op: OpAdd, ID: "c", Item: json.RawMessage(`{"id":"c"}`)}}}}, // no content
+		{f, &Patch{Ops: []PatchOp{{Op: OpReplace, ID: "a", Item: json.RawMessage(`[]`)}}}},
+		{f, &Patch{Ops: []PatchOp{{Op: OpMerge, ID: "a", Item: json.RawMessage(`{`)}}}},
+		{f, &Patch{Ops: []PatchOp{{Op: OpMerge, ID: "a", Item: json.RawMessage(`{"id":"b"}`)}}}},
+		{f, &Patch{Ops: []PatchOp{{Op: OpMerge, ID: "a", Item: json.RawMessage(`{"content_text":null}`)}}}},
+		{badExt, &Patch{Ops: []PatchOp{{Op: OpMerge, ID: "a", Item: json.RawMessage(`{}`)}}}},
+		{f, &Patch{Ops: []PatchOp{{Op: "bogus", ID: "a"}}}},
+		{f, &Patch{Order: []string{"a"}}},
+		{f, &Patch{Order: []string{"a", "a"}}},
+	}
+	for _, test := range cases {
+		_, err := Apply(test.f, test.p)
+		if err == nil {
+			t.Errorf("Apply(%+v) = nil, want error", test.p)
+		}
+	}
+}
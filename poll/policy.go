@@ -0,0 +1,67 @@
+package poll
+
+import (
+	"cmp"
+	"slices"
+	"time"
+)
+
+// Default values for the fields of a Policy.
+const (
+	DefaultMin      = 15 * time.Minute
+	DefaultMax      = 24 * time.Hour
+	DefaultInterval = time.Hour
+)
+
+// A Policy decides how long to wait between polls of a feed.
+// The zero value uses the defaults above.
+type Policy struct {
+	// Min and Max bound the wait between polls.
+	Min, Max time.Duration
+
+	// Interval is the wait between polls of a feed
+	// whose publishing cadence is not yet known.
+	Interval time.Duration
+}
+
+func (p Policy) min() time.Duration { return cmp.Or(p.Min, DefaultMin) }
+func (p Policy) max() time.Duration { return cmp.Or(p.Max, DefaultMax) }
+
+// Wait returns how long to wait before polling again
+// a feed whose items were published at the given times,
+// polled successfully at time now.
+//
+// It polls twice per typical gap between items:
+// half the median of the gaps between successive
+// distinct publication times,
+// or half the time since the most recent item, if that is longer,
+// so that a feed that has gone quiet is polled less often.
+// With fewer than two publication times,
+// it uses p.Interval.
+// The result is between p.Min and p.Max.
+func (p Policy) Wait(published []time.Time, now time.Time) time.Duration {
+	d := cmp.Or(p.Interval, DefaultInterval)
+	times := slices.Clone(published)
+	slices.SortFunc(times, time.Time.Compare)
+	times = slices.CompactFunc(times, time.Time.Equal)
+	if len(times) >= 2 {
+		gaps := make([]time.Duration, len(times)-1)
+		for i := range gaps {
+			gaps[i] = times[i+1].Sub(times[i])
+		}
+		slices.Sort(gaps)
+		d = max(gaps[len(gaps)/2], now.Sub(times[len(times)-1])) / 2
+	}
+	return min(max(d, p.min()), p.max())
+}
+
+// Backoff returns how long to wait before polling again
+// a feed that has failed the given number of times in a row.
+// It doubles with each failure, from p.Min up to p.Max.
+func (p Policy) Backoff(failures int) time.Duration {
+	d := p.min()
+	for i := 1; i < failures && d < p.max(); i++ {
+		d *= 2
+	}
+	return min(d, p.max())
+}
@@ -0,0 +1,61 @@
+package poll
+
+import (
+	"testing"
+	"time"
+)
+
+func TestWait(t *testing.T) {
+	now := time.Date(2017, 5, 17, 12, 0, 0, 0, time.UTC)
+	ago := func(hours ...float64) []time.Time {
+		var times []time.Time
+		for _, h := range hours {
+			times = append(times, now.Add(-time.Duration(h*float64(time.Hour))))
+		}
+		return times
+	}
+	cases := []struct {
+		p         Policy
+		published []time.Time
+		want      time.Duration
+	}{
+		{Policy{}, nil, time.Hour},
+		{Policy{Interval: 3 * time.Hour}, ago(1), 3 * time.Hour},
+		{Policy{}, ago(1, 1), time.Hour},
+		{Policy{}, ago(1, 3, 5, 7), time.Hour},
+		{Policy{}, ago(7, 5, 1, 3), time.Hour},
+		{Policy{}, ago(1, 2, 3, 30), 30 * time.Minute},
+		{Policy{}, ago(1, 1.1, 1.2), 30 * time.Minute},
+		{Policy{}, ago(0, 0.1), 15 * time.Minute},
+		{Policy{Min: time.Minute}, ago(0, 0.1), 3 * time.Minute},
+		{Policy{}, ago(10, 12), 5 * time.Hour},
+		{Policy{}, ago(100, 200), 24 * time.Hour},
+		{Policy{Max: 2 * time.Hour}, ago(10, 12), 2 * time.Hour},
+	}
+	for _, test := range cases {
+		if got := test.p.Wait(test.published, now); got != test.want {
+			t.Errorf("%+v.Wait(%v) = %v, want %v", test.p, test.published, got, test.want)
+		}
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	cases := []struct {
+		p        Policy
+		failures int
+		want     time.Duration
+	}{
+		{Policy{}, 1, 15 * time.Minute},
+		{Policy{}, 2, 30 * time.Minute},
+		{Policy{}, 4, 2 * time.Hour},
+		{Policy{}, 10, 24 * time.Hour},
+		{Policy{}, 1000, 24 * time.Hour},
+		{Policy{Min: time.Minute, Max: 5 * time.Minute}, 3, 4 * time.Minute},
+		{Policy{Min: time.Minute, Max: 5 * time.Minute}, 4, 5 * time.Minute},
+	}
+	for _, test := range cases {
+		if got := test.p.Backoff(test.failures); got != test.want {
+			t.Errorf("%+v.Backoff(%d) = %v, want %v", test.p, test.failures, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,189 @@
+/*
+Package poll fetches JSON feeds repeatedly,
+polling each about as often as it publishes new items.
+
+A Client fetches one feed,
+using conditional requests so that unchanged feeds cost little.
+A Policy decides how long to wait between polls.
+A Scheduler polls many feeds with a bounded number of workers.
+*/
+package poll
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kr/jsonfeed"
+)
+
+// DefaultMaxBytes is the default limit on the size of a feed.
+const DefaultMaxBytes = 10 << 20
+
+// A Client fetches feeds over HTTP.
+// The zero value is ready to use.
+type Client struct {
+	// HTTP is the client used to make requests.
+	// If nil, http.DefaultClient is used.
+	HTTP *http.Client
+
+	// UserAgent, if set, is sent in the User-Agent header.
+	UserAgent string
+
+	// MaxBytes limits the size of a feed.
+	// If zero, DefaultMaxBytes is used.
+	MaxBytes int64
+}
+
+// A Result is the outcome of a successful fetch.
+type Result struct {
+	// Feed is the feed fetched,
+	// or nil if the server said it was not modified.
+	Feed *jsonfeed.Feed
+
+	// ETag and LastModified are the validators
+	// to send with the next request for the feed.
+	ETag, LastModified string
+
+	// MaxAge is how long the server says the feed stays fresh,
+	// from the Cache-Control or Expires header,
+	// or zero if it does not say.
+	MaxAge time.Duration
+
+	// Expired says the feed will never change again,
+	// so there is no point fetching it again:
+	// either the feed says it is expired,
+	// or the server says it is gone, with status 410.
+	// In the latter case, Feed is nil.
+	Expired bool
+}
+
+// A StatusError reports an unsuccessful HTTP status.
+type StatusError struct {
+	Code int
+
+	// RetryAfter is the wait requested in the Retry-After header,
+	// or zero if there was none.
+	RetryAfter time.Duration
+}
+
+func (e *StatusError) Error() string {
+	return "poll: " + strconv.Itoa(e.Code) + " " + http.StatusText(e.Code)
+}
+
+// Fetch fetches the feed at url.
+// If etag or lastModified is set,
+// from the Result of a previous fetch,
+// the request is conditional, and if the feed has not changed,
+// the Result has no Feed.
+// Fetch returns a *StatusError for an HTTP status
+// other than 200, 304, and 410.
+func (c *Client) Fetch(ctx context.Context, url, etag, lastModified string) (*Result, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/feed+json, application/json;q=0.9")
+	if c.UserAgent != "" {
+		req.Header.Set("User-Agent", c.UserAgent)
+	}
+	if etag != "" {
+		req.Header.Set("If-None-Match", etag)
+	}
+	if lastModified != "" {
+		req.Header.Set("If-Modified-Since", lastModified)
+	}
+	hc := c.HTTP
+	if hc == nil {
+		hc = http.DefaultClient
+	}
+	resp, err := hc.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	date := headerTime(resp.Header, "Date", time.Now())
+	res := &Result{
+		ETag:         resp.Header.Get("ETag"),
+		LastModified: resp.Header.Get("Last-Modified"),
+		MaxAge:       maxAge(resp.Header, date),
+	}
+	switch resp.StatusCode {
+	case http.StatusNotModified:
+		if res.ETag == "" {
+			res.ETag = etag
+		}
+		if res.LastModified == "" {
+			res.LastModified = lastModified
+		}
+		return res, nil
+	case http.StatusGone:
+		res.Expired = true
+		return res, nil
+	case http.StatusOK:
+	default:
+		e := &StatusError{Code: resp.StatusCode}
+		if s := resp.Header.Get("Retry-After"); s != "" {
+			if n, err := strconv.Atoi(s); err == nil && n > 0 {
+				e.RetryAfter = time.Duration(n) * time.Second
+			} else if t, err := http.ParseTime(s); err == nil && t.After(date) {
+				e.RetryAfter = t.Sub(date)
+			}
+		}
+		return nil, e
+	}
+
+	max := c.MaxBytes
+	if max == 0 {
+		max = DefaultMaxBytes
+	}
+	b, err := io.ReadAll(io.LimitReader(resp.Body, max+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(b)) > max {
+		return nil, errors.New("poll: feed larger than " + strconv.FormatInt(max, 10) + " bytes")
+	}
+	res.Feed = new(jsonfeed.Feed)
+	if err := json.Unmarshal(b, res.Feed); err != nil {
+		return nil, err
+	}
+	res.Expired = res.Feed.Expired
+	return res, nil
+}
+
+// headerTime returns the time in header h[k],
+// or def if there is none.
+func headerTime(h http.Header, k string, def time.Time) time.Time {
+	if t, err := http.ParseTime(h.Get(k)); err == nil {
+		return t
+	}
+	return def
+}
+
+// maxAge returns the freshness lifetime given in h,
+// with date as the time of the response.
+func maxAge(h http.Header, date time.Time) time.Duration {
+	for _, dir := range strings.Split(h.Get("Cache-Control"), ",") {
+		k, v, _ := strings.Cut(strings.TrimSpace(dir), "=")
+		switch strings.ToLower(k) {
+		case "no-cache", "no-store":
+			return 0
+		case "max-age":
+			if n, err := strconv.Atoi(strings.Trim(v, `"`)); err == nil && n > 0 {
+				return time.Duration(n) * time.Second
+			}
+			return 0
+		}
+	}
+	if t := headerTime(h, "Expires", date); t.After(date) {
+		return t.Sub(date)
+	}
+	return 0
+}
@@ -0,0 +1,169 @@
+package poll
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+const feedJSON = `{
+	"version": "https://jsonfeed.org/version/1",
+	"title": "title",
+	"items": [{"id": "a", "content_text": "a", "date_published": "2017-05-17T10:00:00Z"}]
+}`
+
+func TestFetch(t *testing.T) {
+	date := time.Date(2017, 5, 17, 12, 0, 0, 0, time.UTC)
+	h := http.NewServeMux()
+	h.HandleFunc("/feed", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("User-Agent") != "test" {
+			t.Errorf("User-Agent = %q, want test", r.Header.Get("User-Agent"))
+		}
+		w.Header().Set("Date", date.Format(http.TimeFormat))
+		w.Header().Set("Cache-Control", "public, max-age=600")
+		if r.Header.Get("If-None-Match") == `"v1"` || r.Header.Get("If-Modified-Since") == "then" {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		w.Header().Set("Last-Modified", "then")
+		w.Write([]byte(feedJSON))
+	})
+	h.HandleFunc("/expires", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Date", date.Format(http.TimeFormat))
+		w.Header().Set("Expires", date.Add(time.Hour).Format(http.TimeFormat))
+		w.Write([]byte(feedJSON))
+	})
+	h.HandleFunc("/expired", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(strings.Replace(feedJSON, "{", `{"expired": true,`, 1)))
+	})
+	h.HandleFunc("/gone", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusGone)
+	})
+	srv := httptest.NewServer(h)
+	defer srv.Close()
+
+	c := &Client{UserAgent: "test"}
+	ctx := context.Background()
+	res, err := c.Fetch(ctx, srv.URL+"/feed", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Feed == nil || res.Feed.Title != "title" || res.ETag != `"v1"` || res.LastModified != "then" || res.MaxAge != 10*time.Minute {
+		t.Errorf("Fetch() = %+v", res)
+	}
+	res, err = c.Fetch(ctx, srv.URL+"/feed", `"v1"`, "")
+	if err != nil || res.Feed != nil || res.ETag != `"v1"` || res.LastModified != "" {
+		t.Errorf("Fetch(If-None-Match) = %+v, %v, want not modified", res, err)
+	}
+	res, err = c.Fetch(ctx, srv.URL+"/feed", "", "then")
+	if err != nil || res.Feed != nil || res.LastModified != "then" {
+		t.Errorf("Fetch(If-Modified-Since) = %+v, %v, want not modified", res, err)
+	}
+	if res.Expired {
+		t.Errorf("Fetch(If-Modified-Since) = %+v, want not expired", res)
+	}
+	res, err = c.Fetch(ctx, srv.URL+"/expires", "", "")
+	if err != nil || res.MaxAge != time.Hour {
+		t.Errorf("Fetch(Expires) = %+v, %v, want MaxAge 1h", res, err)
+	}
+	res, err = c.Fetch(ctx, srv.URL+"/expired", "", "")
+	if err != nil || res.Feed == nil || !res.Expired {
+		t.Errorf("Fetch(expired feed) = %+v, %v, want expired", res, err)
+	}
+	res, err = c.Fetch(ctx, srv.URL+"/gone", "", "")
+	if err != nil || res.Feed != nil || !res.Expired {
+		t.Errorf("Fetch(410) = %+v, %v, want expired", res, err)
+	}
+}
+
+func TestFetchErrors(t *testing.T) {
+	date := time.Date(2017, 5, 17, 12, 0, 0, 0, time.UTC)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/retry-seconds":
+			w.Header().Set("Retry-After", "120")
+			w.WriteHeader(http.StatusServiceUnavailable)
+		case "/retry-date":
+			w.Header().Set("Date", date.Format(http.TimeFormat))
+			w.Header().Set("Retry-After", date.Add(time.Hour).Format(http.TimeFormat))
+			w.WriteHeader(http.StatusTooManyRequests)
+		case "/retry-bad":
+			w.Header().Set("Retry-After", "soon")
+			w.WriteHeader(http.StatusTooManyRequests)
+		case "/missing":
+			w.WriteHeader(http.StatusNotFound)
+		case "/big":
+			w.Write([]byte(feedJSON + strings.Repeat(" ", 100)))
+		case "/invalid":
+			w.Write([]byte(`{"items": []}`))
+		case "/short":
+			w.Header().Set("Content-Length", "100")
+			w.Write([]byte("{"))
+		}
+	}))
+	defer srv.Close()
+
+	c := &Client{MaxBytes: int64(len(feedJSON) + 50)}
+	ctx := context.Background()
+	cases := []struct {
+		path  string
+		code  int
+		retry time.Duration
+	}{
+		{"/retry-seconds", 503, 2 * time.Minute},
+		{"/retry-date", 429, time.Hour},
+		{"/retry-bad", 429, 0},
+		{"/missing", 404, 0},
+	}
+	for _, test := range cases {
+		_, err := c.Fetch(ctx, srv.URL+test.path, "", "")
+		var se *StatusError
+		if !errors.As(err, &se) || se.Code != test.code || se.RetryAfter != test.retry {
+			t.Errorf("Fetch(%s) = %v, want status %d, retry %v", test.path, err, test.code, test.retry)
+		}
+	}
+	if _, err := c.Fetch(ctx, srv.URL+"/missing", "", ""); err.Error() != "poll: 404 Not Found" {
+		t.Errorf("Error() = %q", err)
+	}
+	for _, path := range []string{"/big", "/invalid", "/short"} {
+		if res, err := c.Fetch(ctx, srv.URL+path, "", ""); err == nil {
+			t.Errorf("Fetch(%s) = %+v, want error", path, res)
+		}
+	}
+	for _, u := range []string{"%", "http://127.0.0.1:0/"} {
+		if res, err := c.Fetch(ctx, u, "", ""); err == nil {
+			t.Errorf("Fetch(%s) = %+v, want error", u, res)
+		}
+	}
+}
+
+func TestMaxAge(t *testing.T) {
+	date := time.Date(2017, 5, 17, 12, 0, 0, 0, time.UTC)
+	cases := []struct {
+		cc, expires string
+		want        time.Duration
+	}{
+		{"", "", 0},
+		{"max-age=60", "", time.Minute},
+		{`max-age="60"`, "", time.Minute},
+		{"no-cache, max-age=60", "", 0},
+		{"no-store", date.Add(time.Hour).Format(http.TimeFormat), 0},
+		{"max-age=-1", date.Add(time.Hour).Format(http.TimeFormat), 0},
+		{"private", date.Add(time.Hour).Format(http.TimeFormat), time.Hour},
+		{"", date.Add(-time.Hour).Format(http.TimeFormat), 0},
+		{"", "0", 0},
+	}
+	for _, test := range cases {
+		h := http.Header{}
+		h.Set("Cache-Control", test.cc)
+		h.Set("Expires", test.expires)
+		if got := maxAge(h, date); got != test.want {
+			t.Errorf("maxAge(%q, %q) = %v, want %v", test.cc, test.expires, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,311 @@
+package poll
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/kr/jsonfeed"
+)
+
+// A Clock tells the time and waits.
+// Tests can provide a fake Clock to control a Scheduler.
+type Clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time                         { return time.Now() }
+func (systemClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
+// maxPublished is the number of publication times
+// kept in State.Published.
+const maxPublished = 50
+
+// A State is what a Scheduler knows about one feed.
+type State struct {
+	URL string
+
+	// Feed is the feed as most recently fetched,
+	// or nil if it has not been fetched yet.
+	Feed *jsonfeed.Feed
+
+	// ETag and LastModified are validators
+	// for conditional requests.
+	ETag, LastModified string
+
+	// Published holds the distinct publication times
+	// of the feed's items, oldest first,
+	// as observed by this Scheduler.
+	// It holds at most the last 50.
+	Published []time.Time
+
+	// Failures is the number of polls in a row that failed,
+	// and Err is the error from the last one.
+	Failures int
+	Err      error
+
+	// LastPoll is when the feed was last polled,
+	// and Next is when it will be polled again.
+	LastPoll, Next time.Time
+
+	// Expired says the feed will not be polled again,
+	// because the feed says it is expired,
+	// or the server says it is gone.
+	Expired bool
+}
+
+// A Scheduler polls a set of feeds,
+// each when its Policy says to.
+// Its fields must be set before calling Run.
+type Scheduler struct {
+	// Client fetches the feeds.
+	// If nil, a zero Client is used.
+	Client *Client
+
+	// Policy decides when to poll each feed.
+	// A feed whose server gives it a longer freshness lifetime
+	// than Policy.Wait, up to Policy.Max,
+	// is not polled until it is stale,
+	// and a failed feed whose server asks for a longer wait
+	// than Policy.Backoff gets it.
+	Policy Policy
+
+	// Clock tells the time.
+	// If nil, the system clock is used.
+	Clock Clock
+
+	// Workers is the number of feeds to poll at once.
+	// If it is zero or negative, 4 are polled at once.
+	Workers int
+
+	// Update, if set, is called with each new version of a feed.
+	// Error, if set, is called with each failure to poll.
+	// They are called from worker goroutines,
+	// possibly concurrently.
+	Update func(url string, f *jsonfeed.Feed)
+	Error  func(url string, err error)
+
+	mu    sync.Mutex
+	feeds map[string]*entry
+	wake  chan struct{} // buffered; signals a change to feeds
+}
+
+type entry struct {
+	State
+	busy bool // being polled
+}
+
+func (s *Scheduler) clock() Clock {
+	if s.Clock == nil {
+		return systemClock{}
+	}
+	return s.Clock
+}
+
+// init initializes s.
+// The caller must hold s.mu.
+func (s *Scheduler) init() {
+	if s.feeds == nil {
+		s.feeds = make(map[string]*entry)
+		s.wake = make(chan struct{}, 1)
+	}
+}
+
+// wakeup tells Run to check the feeds again.
+func (s *Scheduler) wakeup() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Add adds the feed at url to the feeds polled by s.
+// It is polled right away.
+// Adding a feed already in s has no effect.
+func (s *Scheduler) Add(url string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.init()
+	if s.feeds[url] == nil {
+		s.feeds[url] = &entry{State: State{URL: url}}
+		s.wakeup()
+	}
+}
+
+// Remove removes the feed at url from s.
+// A poll of the feed in progress is finished,
+// but its result is ignored.
+func (s *Scheduler) Remove(url string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.feeds, url)
+}
+
+// State returns the state of the feed at url,
+// and whether it is in s.
+func (s *Scheduler) State(url string) (State, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e := s.feeds[url]
+	if e == nil {
+		return State{}, false
+	}
+	return e.State, true
+}
+
+// Run polls the feeds until ctx is done,
+// then waits for polls in progress to finish
+// and returns ctx.Err().
+func (s *Scheduler) Run(ctx context.Context) error {
+	s.mu.Lock()
+	s.init()
+	s.mu.Unlock()
+	n := s.Workers
+	if n <= 0 {
+		n = 4
+	}
+	work := make(chan *entry)
+	var wg sync.WaitGroup
+	for range n {
+		wg.Go(func() {
+			for e := range work {
+				s.poll(ctx, e)
+			}
+		})
+	}
+	defer wg.Wait()
+	defer close(work)
+
+	for {
+		due, wait := s.due(s.clock().Now())
+		for i, e := range due {
+			select {
+			case work <- e:
+			case <-ctx.Done():
+				s.mu.Lock()
+				for _, e := range due[i:] {
+					e.busy = false
+				}
+				s.mu.Unlock()
+				return ctx.Err()
+			}
+		}
+		if len(due) > 0 {
+			continue // check again, in case time has passed
+		}
+		var timer <-chan time.Time
+		if wait > 0 {
+			timer = s.clock().After(wait)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-timer:
+		case <-s.wake:
+		}
+	}
+}
+
+// due returns the feeds due to be polled at time now,
+// in order of URL, marking them busy,
+// and the wait until the next one is due,
+// or 0 if none is scheduled.
+func (s *Scheduler) due(now time.Time) (due []*entry, wait time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, e := range s.feeds {
+		switch {
+		case e.busy || e.Expired:
+		case !e.Next.After(now):
+			e.busy = true
+			due = append(due, e)
+		case wait == 0 || e.Next.Sub(now) < wait:
+			wait = e.Next.Sub(now)
+		}
+	}
+	sort.Slice(due, func(i, j int) bool { return due[i].URL < due[j].URL })
+	return due, wait
+}
+
+// poll polls the feed e and schedules its next poll.
+func (s *Scheduler) poll(ctx context.Context, e *entry) {
+	defer s.wakeup()
+	s.mu.Lock()
+	url, etag, lastModified := e.URL, e.ETag, e.LastModified
+	s.mu.Unlock()
+	start := s.clock().Now()
+	res, err := s.client().Fetch(ctx, url, etag, lastModified)
+	now := s.clock().Now()
+
+	s.mu.Lock()
+	e.busy = false
+	if ctx.Err() != nil || s.feeds[url] != e {
+		s.mu.Unlock()
+		return // canceled or removed
+	}
+	e.LastPoll = now
+	if err != nil {
+		e.Failures++
+		e.Err = err
+		wait := s.Policy.Backoff(e.Failures)
+		if se := (*StatusError)(nil); errors.As(err, &se) {
+			wait = max(wait, se.RetryAfter)
+		}
+		e.Next = now.Add(wait)
+		s.mu.Unlock()
+		if s.Error != nil {
+			s.Error(url, err)
+		}
+		return
+	}
+	e.Failures, e.Err = 0, nil
+	e.ETag, e.LastModified = res.ETag, res.LastModified
+	e.Expired = res.Expired
+	if res.Feed != nil {
+		e.Feed = res.Feed
+		e.Published = addPublished(e.Published, res.Feed, start)
+	}
+	wait := s.Policy.Wait(e.Published, now)
+	e.Next = now.Add(max(wait, min(res.MaxAge, s.Policy.max())))
+	s.mu.Unlock()
+	if res.Feed != nil && s.Update != nil {
+		s.Update(url, res.Feed)
+	}
+}
+
+func (s *Scheduler) client() *Client {
+	if s.Client == nil {
+		return new(Client)
+	}
+	return s.Client
+}
+
+// addPublished adds the publication times of the items in f
+// to the sorted list times,
+// keeping the last maxPublished distinct times.
+// It ignores times not before start, when f was requested,
+// since unmarshaling gives items with no publication date
+// the current time.
+func addPublished(times []time.Time, f *jsonfeed.Feed, start time.Time) []time.Time {
+	seen := make(map[time.Time]bool, len(times))
+	for _, t := range times {
+		seen[t] = true
+	}
+	for _, t := range f.Items {
+		d := t.DatePublished.UTC()
+		if d.Before(start) && !seen[d] {
+			seen[d] = true
+			times = append(times, d)
+		}
+	}
+	sort.Slice(times, func(i, j int) bool { return times[i].Before(times[j]) })
+	if len(times) > maxPublished {
+		times = times[len(times)-maxPublished:]
+	}
+	return times
+}
@@ -0,0 +1,290 @@
+package poll
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kr/jsonfeed"
+)
+
+// A fakeClock is a Clock whose time changes only when advanced.
+type fakeClock struct {
+	mu     sync.Mutex
+	now    time.Time
+	timers []fakeTimer
+}
+
+type fakeTimer struct {
+	when time.Time
+	c    chan time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) After(d time.Duration) <-chan time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	ch := make(chan time.Time, 1)
+	c.timers = append(c.timers, fakeTimer{c.now.Add(d), ch})
+	return ch
+}
+
+// Advance moves the clock forward by d,
+// firing any timers that come due.
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+	timers := c.timers[:0]
+	for _, t := range c.timers {
+		if t.when.After(c.now) {
+			timers = append(timers, t)
+		} else {
+			t.c <- c.now
+		}
+	}
+	c.timers = timers
+}
+
+// waitFor waits for cond to become true.
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	for deadline := time.Now().Add(5 * time.Second); !cond(); {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+const feedTemplate = `{
+	"version": "https://jsonfeed.org/version/1",
+	"title": "title",
+	"expired": %s,
+	"items": [
+		{"id": "a", "content_text": "a", "date_published": "2017-05-17T07:00:00Z"},
+		{"id": "b", "content_text": "b", "date_published": "2017-05-17T09:00:00Z"},
+		{"id": "c", "content_text": "c", "date_published": "2017-05-17T11:00:00Z"},
+		{"id": "d", "content_text": "d"}
+	]
+}`
+
+func TestScheduler(t *testing.T) {
+	var mu sync.Mutex
+	requests := make(map[string]int)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests[r.URL.Path]++
+		mu.Unlock()
+		switch r.URL.Path {
+		case "/live":
+			if r.Header.Get("If-None-Match") == `"v1"` {
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+			w.Header().Set("ETag", `"v1"`)
+			w.Write([]byte(strings.Replace(feedTemplate, "%s", "false", 1)))
+		case "/cached":
+			w.Header().Set("Cache-Control", "max-age=36000")
+			w.Write([]byte(strings.Replace(feedTemplate, "%s", "false", 1)))
+		case "/expired":
+			w.Write([]byte(strings.Replace(feedTemplate, "%s", "true", 1)))
+		case "/busy":
+			w.Header().Set("Retry-After", "7200")
+			w.WriteHeader(http.StatusServiceUnavailable)
+		case "/gone":
+			w.WriteHeader(http.StatusGone)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	now := time.Date(2017, 5, 17, 12, 0, 0, 0, time.UTC)
+	clock := &fakeClock{now: now}
+	var updates, errs []string
+	s := &Scheduler{
+		Client:  &Client{UserAgent: "test"},
+		Clock:   clock,
+		Workers: 2,
+		Update: func(url string, f *jsonfeed.Feed) {
+			mu.Lock()
+			defer mu.Unlock()
+			updates = append(updates, url)
+		},
+		Error: func(url string, err error) {
+			mu.Lock()
+			defer mu.Unlock()
+			errs = append(errs, url)
+		},
+	}
+	paths := []string{"/live", "/cached", "/expired", "/busy", "/gone", "/missing"}
+	for _, p := range paths {
+		s.Add(srv.URL + p)
+	}
+	s.Add(srv.URL + "/live") // no effect
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- s.Run(ctx) }()
+
+	polled := func(p string, at time.Time) func() bool {
+		return func() bool {
+			// Run may have computed its wait
+			// before the clock last moved.
+			s.wakeup()
+			st, _ := s.State(srv.URL + p)
+			return st.LastPoll.Equal(at)
+		}
+	}
+	for _, p := range paths {
+		waitFor(t, p+" poll", polled(p, now))
+	}
+	want := map[string]struct {
+		next     time.Duration
+		failures int
+		expired  bool
+	}{
+		"/live":    {time.Hour, 0, false}, // 2h between items
+		"/cached":  {10 * time.Hour, 0, false},
+		"/expired": {time.Hour, 0, true},
+		"/busy":    {2 * time.Hour, 1, false},
+		"/gone":    {time.Hour, 0, true},
+		"/missing": {15 * time.Minute, 1, false},
+	}
+	for p, w := range want {
+		st, ok := s.State(srv.URL + p)
+		if !ok || !st.Next.Equal(now.Add(w.next)) || st.Failures != w.failures || st.Expired != w.expired {
+			t.Errorf("State(%s) = %+v, want next in %v, %d failures, expired %v", p, st, w.next, w.failures, w.expired)
+		}
+	}
+	st, _ := s.State(srv.URL + "/live")
+	if len(st.Published) != 3 || st.Feed == nil || st.ETag != `"v1"` {
+		t.Errorf("State(/live) = %+v, want 3 publication times, feed, and etag", st)
+	}
+	var se *StatusError
+	if st, _ := s.State(srv.URL + "/busy"); !errors.As(st.Err, &se) {
+		t.Errorf("State(/busy).Err = %v, want *StatusError", st.Err)
+	}
+	if _, ok := s.State(srv.URL + "/none"); ok {
+		t.Errorf("State(/none) found")
+	}
+
+	// After 15 minutes, the failed feeds are retried,
+	// except for the gone one.
+	clock.Advance(15 * time.Minute)
+	waitFor(t, "/missing retry", polled("/missing", now.Add(15*time.Minute)))
+	if st, _ := s.State(srv.URL + "/missing"); st.Failures != 2 || !st.Next.Equal(now.Add(45*time.Minute)) {
+		t.Errorf("State(/missing) = %+v, want 2 failures, next in 45m", st)
+	}
+
+	// After an hour, the live feed is polled and has not changed.
+	s.Remove(srv.URL + "/missing")
+	clock.Advance(45 * time.Minute)
+	waitFor(t, "/live poll", polled("/live", now.Add(time.Hour)))
+	st, _ = s.State(srv.URL + "/live")
+	if st.Feed == nil || len(st.Published) != 3 || !st.Next.Equal(now.Add(2*time.Hour)) {
+		t.Errorf("State(/live) = %+v, want unchanged feed, next in 1h", st)
+	}
+
+	cancel()
+	if err := <-done; err != context.Canceled {
+		t.Errorf("Run() = %v, want context.Canceled", err)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if requests["/gone"] != 1 || requests["/expired"] != 1 || requests["/cached"] != 1 || requests["/live"] != 2 {
+		t.Errorf("requests = %v", requests)
+	}
+	if len(updates) != 3 || len(errs) != 3 {
+		t.Errorf("updates = %q, errors = %q, want 3 and 3", updates, errs)
+	}
+}
+
+func TestSchedulerCancel(t *testing.T) {
+	release := make(chan struct{})
+	started := make(chan string, 10)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		started <- r.URL.Path
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+		w.Write([]byte(strings.Replace(feedTemplate, "%s", "false", 1)))
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	// A feed removed while it is being polled is forgotten.
+	s := &Scheduler{Clock: &fakeClock{}, Workers: 1}
+	s.Update = func(url string, f *jsonfeed.Feed) { t.Errorf("Update(%s) after Remove", url) }
+	s.Add(srv.URL + "/a")
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- s.Run(ctx) }()
+	<-started
+	s.Remove(srv.URL + "/a")
+	release <- struct{}{}
+
+	// Canceling stops polls in progress
+	// and polls waiting for a worker.
+	s.Add(srv.URL + "/b")
+	s.Add(srv.URL + "/c")
+	<-started
+	cancel()
+	if err := <-done; err != context.Canceled {
+		t.Errorf("Run() = %v, want context.Canceled", err)
+	}
+	for _, p := range []string{"/b", "/c"} {
+		if st, _ := s.State(srv.URL + p); !st.LastPoll.IsZero() {
+			t.Errorf("State(%s) = %+v, want not polled", p, st)
+		}
+	}
+}
+
+func TestSchedulerDefaults(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(strings.Replace(feedTemplate, "%s", "false", 1)))
+	}))
+	defer srv.Close()
+
+	for _, workers := range []int{0, -1} {
+		updated := make(chan string, 1)
+		s := &Scheduler{Workers: workers, Update: func(url string, f *jsonfeed.Feed) { updated <- url }}
+		s.Add(srv.URL)
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan error)
+		go func() { done <- s.Run(ctx) }()
+		<-updated
+		cancel()
+		if err := <-done; err != context.Canceled {
+			t.Errorf("Workers %d: Run() = %v, want context.Canceled", workers, err)
+		}
+	}
+}
+
+func TestAddPublished(t *testing.T) {
+	start := time.Date(2017, 5, 17, 12, 0, 0, 0, time.UTC)
+	f := new(jsonfeed.Feed)
+	for i := range 60 {
+		f.Items = append(f.Items, jsonfeed.Item{DatePublished: start.Add(time.Duration(i-60) * time.Hour)})
+	}
+	f.Items = append(f.Items, f.Items[59], jsonfeed.Item{DatePublished: start})
+	got := addPublished(nil, f, start)
+	if len(got) != maxPublished || !got[0].Equal(start.Add(-50*time.Hour)) {
+		t.Errorf("addPublished() = %v, want last %d hours", got, maxPublished)
+	}
+	if got := addPublished(got, f, start); len(got) != maxPublished {
+		t.Errorf("addPublished(again) = %v, want unchanged", got)
+	}
+}
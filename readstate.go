@@ -0,0 +1,259 @@
+package jsonfeed
+
+import (
+	"encoding/json"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+)
+
+// A ReaderState records which items a reader has read
+// and which they have starred.
+// Items are identified by a key for their feed,
+// such as its FeedURL, and by their ID,
+// which the spec says is unique within the feed.
+//
+// The zero value is an empty state ready to use.
+// A ReaderState is safe for use by multiple goroutines.
+type ReaderState struct {
+	// Resurface makes a read item unread again when it is updated,
+	// that is, when its DateModified is later than it was
+	// when it was read and its title or content has changed.
+	// Checking the content too means that feeds that update
+	// DateModified needlessly, or leave it out so that
+	// unmarshaling sets it to the current time,
+	// don't resurface items.
+	Resurface bool
+
+	mu    sync.Mutex
+	feeds map[string]map[string]*itemState // by feed key and item ID
+}
+
+// An itemState is the state of one item.
+// Items in neither state have no itemState.
+type itemState struct {
+	Read     time.Time `json:"read,omitzero"`     // when marked read; zero if unread
+	Modified time.Time `json:"modified,omitzero"` // DateModified when read
+	Hash     string    `json:"hash,omitempty"`    // contentHash when read
+	Starred  bool      `json:"starred,omitempty"`
+}
+
+// item returns the state of the given item,
+// creating it if create is set,
+// or else returning nil if there is none.
+// The caller must hold s.mu.
+func (s *ReaderState) item(key, id string, create bool) *itemState {
+	st := s.feeds[key][id]
+	if st != nil || !create {
+		return st
+	}
+	if s.feeds == nil {
+		s.feeds = make(map[string]map[string]*itemState)
+	}
+	if s.feeds[key] == nil {
+		s.feeds[key] = make(map[string]*itemState)
+	}
+	st = new(itemState)
+	s.feeds[key][id] = st
+	return st
+}
+
+// drop deletes the state of the given item
+// if it is neither read nor starred.
+// The caller must hold s.mu.
+func (s *ReaderState) drop(key, id string) {
+	if st := s.feeds[key][id]; st != nil && st.Read.IsZero() && !st.Starred {
+		delete(s.feeds[key], id)
+		if len(s.feeds[key]) == 0 {
+			delete(s.feeds, key)
+		}
+	}
+}
+
+// MarkRead records that t, an item in the feed with the given key,
+// was read at time now.
+func (s *ReaderState) MarkRead(key string, t *Item, now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.markRead(key, t, now)
+}
+
+// markRead implements MarkRead.
+// The caller must hold s.mu.
+func (s *ReaderState) markRead(key string, t *Item, now time.Time) {
+	st := s.item(key, t.ID, true)
+	st.Read, st.Modified, st.Hash = now, t.DateModified, contentHash(t)
+}
+
+// MarkUnread records that the item with the given ID
+// in the feed with the given key is unread.
+func (s *ReaderState) MarkUnread(key, id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if st := s.item(key, id, false); st != nil {
+		*st = itemState{Starred: st.Starred}
+		s.drop(key, id)
+	}
+}
+
+// MarkReadBefore marks as read, at time now,
+// each unread item in f, the feed with the given key,
+// dated before the given time,
+// using DatePublished, or DateModified for items with no DatePublished.
+// It returns the number of items it marked.
+func (s *ReaderState) MarkReadBefore(key string, f *Feed, before, now time.Time) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n := 0
+	for i := range f.Items {
+		t := &f.Items[i]
+		if itemDate(t).Before(before) && !s.isRead(key, t) {
+			s.markRead(key, t, now)
+			n++
+		}
+	}
+	return n
+}
+
+// IsRead returns whether t, an item in the feed with the given key,
+// has been read.
+// See Resurface for items updated since they were read.
+func (s *ReaderState) IsRead(key string, t *Item) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.isRead(key, t)
+}
+
+// isRead implements IsRead.
+// The caller must hold s.mu.
+func (s *ReaderState) isRead(key string, t *Item) bool {
+	st := s.item(key, t.ID, false)
+	if st == nil || st.Read.IsZero() {
+		return false
+	}
+	if s.Resurface && t.DateModified.After(st.Modified) && contentHash(t) != st.Hash {
+		return false
+	}
+	return true
+}
+
+// SetStarred stars or unstars the item with the given ID
+// in the feed with the given key.
+func (s *ReaderState) SetStarred(key, id string, starred bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if st := s.item(key, id, starred); st != nil {
+		st.Starred = starred
+		s.drop(key, id)
+	}
+}
+
+// IsStarred returns whether the item with the given ID
+// in the feed with the given key is starred.
+func (s *ReaderState) IsStarred(key, id string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	st := s.item(key, id, false)
+	return st != nil && st.Starred
+}
+
+// Starred returns the IDs of the starred items
+// in the feed with the given key, sorted.
+// They need not still be in the feed.
+func (s *ReaderState) Starred(key string) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var ids []string
+	for id, st := range s.feeds[key] {
+		if st.Starred {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// Unread returns the number of unread items in f,
+// the feed with the given key.
+func (s *ReaderState) Unread(key string, f *Feed) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n := 0
+	for i := range f.Items {
+		if !s.isRead(key, &f.Items[i]) {
+			n++
+		}
+	}
+	return n
+}
+
+// UnreadCounts returns the number of unread items
+// in each of feeds, which are keyed by feed key.
+func (s *ReaderState) UnreadCounts(feeds map[string]*Feed) map[string]int {
+	counts := make(map[string]int, len(feeds))
+	for key, f := range feeds {
+		counts[key] = s.Unread(key, f)
+	}
+	return counts
+}
+
+// Prune forgets the read state of items no longer in f,
+// the feed with the given key,
+// so the state doesn't grow without bound.
+// Starred items are kept.
+// An item pruned while read will be unread
+// if it returns to the feed.
+func (s *ReaderState) Prune(key string, f *Feed) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	in := make(map[string]bool, len(f.Items))
+	for i := range f.Items {
+		in[f.Items[i].ID] = true
+	}
+	for id, st := range s.feeds[key] {
+		if !in[id] {
+			st.Read = time.Time{}
+			s.drop(key, id)
+		}
+	}
+}
+
+// readerStateJSON is the JSON encoding of a ReaderState.
+type readerStateJSON struct {
+	Resurface bool                             `json:"resurface,omitempty"`
+	Feeds     map[string]map[string]*itemState `json:"feeds"`
+}
+
+// MarshalJSON encodes s as a JSON object.
+// The format is stable, so it can be stored and read back
+// by later versions of this package.
+func (s *ReaderState) MarshalJSON() ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	v := readerStateJSON{Resurface: s.Resurface, Feeds: s.feeds}
+	if v.Feeds == nil {
+		v.Feeds = map[string]map[string]*itemState{} // avoid emitting JSON 'null'
+	}
+	return json.Marshal(&v)
+}
+
+// UnmarshalJSON replaces s with the state encoded in b
+// by MarshalJSON.
+func (s *ReaderState) UnmarshalJSON(b []byte) error {
+	var v readerStateJSON
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+	for key, items := range v.Feeds {
+		for id, st := range items {
+			if st == nil {
+				return errors.New("jsonfeed: null state for item " + id + " in feed " + key)
+			}
+		}
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Resurface, s.feeds = v.Resurface, v.Feeds
+	return nil
+}
@@ -0,0 +1,133 @@
+package jsonfeed
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestReaderState(t *testing.T) {
+	d := time.Date(2017, 5, 17, 0, 0, 0, 0, time.UTC)
+	now := d.Add(24 * time.Hour)
+	f := &Feed{Items: []Item{
+		{ID: "a", ContentText: "a", DatePublished: d, DateModified: d},
+		{ID: "b", ContentText: "b", DatePublished: d.Add(time.Hour), DateModified: d.Add(time.Hour)},
+		{ID: "c", ContentText: "c", DateModified: d.Add(2 * time.Hour)},
+	}}
+
+	var s ReaderState
+	if n := s.Unread("f", f); n != 3 {
+		t.Errorf("Unread() = %d, want 3", n)
+	}
+	s.MarkRead("f", &f.Items[0], now)
+	if !s.IsRead("f", &f.Items[0]) || s.IsRead("f", &f.Items[1]) || s.IsRead("g", &f.Items[0]) {
+		t.Errorf("after MarkRead(a), IsRead wrong")
+	}
+	if n := s.MarkReadBefore("f", f, d.Add(2*time.Hour), now); n != 1 {
+		t.Errorf("MarkReadBefore() = %d, want 1", n)
+	}
+	got := s.UnreadCounts(map[string]*Feed{"f": f, "g": f})
+	if want := map[string]int{"f": 1, "g": 3}; !reflect.DeepEqual(got, want) {
+		t.Errorf("UnreadCounts() = %v, want %v", got, want)
+	}
+
+	s.SetStarred("f", "b", true)
+	s.SetStarred("f", "x", true)
+	s.SetStarred("f", "x", false)
+	s.SetStarred("f", "y", false)
+	if !s.IsStarred("f", "b") || s.IsStarred("f", "a") || s.IsStarred("f", "x") {
+		t.Errorf("IsStarred wrong after SetStarred")
+	}
+	if got, want := s.Starred("f"), []string{"b"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Starred() = %q, want %q", got, want)
+	}
+
+	s.MarkUnread("f", "b")
+	s.MarkUnread("f", "none")
+	if s.IsRead("f", &f.Items[1]) || !s.IsStarred("f", "b") {
+		t.Errorf("after MarkUnread(b), IsRead or IsStarred wrong")
+	}
+	s.MarkUnread("f", "a")
+	s.SetStarred("f", "b", false)
+	if s.feeds != nil && len(s.feeds) != 0 {
+		t.Errorf("state = %v, want empty", s.feeds)
+	}
+}
+
+func TestReaderStateResurface(t *testing.T) {
+	d := time.Date(2017, 5, 17, 0, 0, 0, 0, time.UTC)
+	item := Item{ID: "a", ContentText: "a", DateModified: d}
+	s := &ReaderState{Resurface: true}
+	s.MarkRead("f", &item, d.Add(time.Hour))
+
+	bumped := item
+	bumped.DateModified = d.Add(2 * time.Hour)
+	edited := bumped
+	edited.ContentText = "a, edited"
+	old := edited
+	old.DateModified = d
+
+	cases := []struct {
+		t         *Item
+		resurface bool
+		want      bool
+	}{
+		{&item, true, true},
+		{&bumped, true, true},
+		{&edited, true, false},
+		{&old, true, true},
+		{&edited, false, true},
+	}
+	for _, test := range cases {
+		s.Resurface = test.resurface
+		if got := s.IsRead("f", test.t); got != test.want {
+			t.Errorf("IsRead(%+v) with Resurface=%v = %v, want %v", test.t, test.resurface, got, test.want)
+		}
+	}
+}
+
+func TestReaderStatePrune(t *testing.T) {
+	var s ReaderState
+	now := time.Date(2017, 5, 17, 0, 0, 0, 0, time.UTC)
+	for _, id := range []string{"a", "b", "c"} {
+		s.MarkRead("f", &Item{ID: id}, now)
+	}
+	s.SetStarred("f", "b", true)
+	s.Prune("f", &Feed{Items: []Item{{ID: "c"}}})
+	if got := len(s.feeds["f"]); got != 2 {
+		t.Errorf("len(state) = %d, want 2", got)
+	}
+	if s.IsRead("f", &Item{ID: "b"}) || !s.IsStarred("f", "b") || !s.IsRead("f", &Item{ID: "c"}) {
+		t.Errorf("Prune kept wrong state: %v", s.feeds["f"])
+	}
+}
+
+func TestReaderStateJSON(t *testing.T) {
+	var s ReaderState
+	if b, _ := json.Marshal(&s); string(b) != `{"feeds":{}}` {
+		t.Errorf("Marshal(empty) = %s", b)
+	}
+
+	d := time.Date(2017, 5, 17, 0, 0, 0, 0, time.UTC)
+	s.Resurface = true
+	s.MarkRead("f", &Item{ID: "a", ContentText: "a", DateModified: d}, d)
+	s.SetStarred("f", "b", true)
+	b, err := json.Marshal(&s)
+	if err != nil {
+		t.Fatalf("Marshal() = %v", err)
+	}
+	var s1 ReaderState
+	if err := json.Unmarshal(b, &s1); err != nil {
+		t.Fatalf("Unmarshal(%s) = %v", b, err)
+	}
+	if !s1.Resurface || !reflect.DeepEqual(s1.feeds, s.feeds) {
+		t.Errorf("Unmarshal(%s) = %+v, want %+v", b, s1.feeds, s.feeds)
+	}
+
+	for _, in := range []string{`[]`, `{"feeds":{"f":{"a":null}}}`} {
+		if err := json.Unmarshal([]byte(in), &s1); err == nil {
+			t.Errorf("Unmarshal(%s) = nil, want error", in)
+		}
+	}
+}
@@ -0,0 +1,136 @@
+package serve
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/kr/jsonfeed"
+)
+
+// DefaultBuffer is the number of updates queued
+// for a subscriber that asks for no buffer,
+// including each client of an EventHandler whose Buffer is zero.
+const DefaultBuffer = 64
+
+// History is the number of recently added items
+// a Publisher keeps,
+// for EventHandler clients that reconnect.
+const History = 100
+
+// An EventHandler streams the items added to a Publisher's feed
+// to its clients as Server-Sent Events.
+//
+// Each event's data is an item, encoded in JSON,
+// and its ID is the item's ID.
+// Items added by one change are sent in reverse order,
+// oldest first, since the feed lists the newest first.
+// A client that reconnects,
+// as browsers do automatically,
+// gets the items it missed,
+// as long as the last one it saw
+// is among the most recent History items added to the feed.
+// Otherwise, it gets only the items added from then on,
+// and should fetch the feed to catch up.
+// Items whose IDs contain line breaks,
+// which can't be sent as event IDs, are sent with no ID.
+//
+// A client that falls more than Buffer updates behind
+// is disconnected, so slow clients don't hold up the rest.
+type EventHandler struct {
+	// Publisher holds the feed to stream.
+	Publisher *Publisher
+
+	// Buffer is the number of updates to queue for each client.
+	// If zero or negative, DefaultBuffer is used.
+	Buffer int
+
+	// Heartbeat, if set, is the interval at which to send
+	// a comment to idle clients,
+	// so that proxies don't close the connection.
+	Heartbeat time.Duration
+}
+
+// An event is one Server-Sent Event.
+type event struct {
+	id   string
+	data []byte
+}
+
+// write writes e to w in the event stream format.
+func (e event) write(w http.ResponseWriter) error {
+	var b strings.Builder
+	if e.id != "" && !strings.ContainsAny(e.id, "\r\n") {
+		b.WriteString("id: " + e.id + "\n")
+	}
+	b.WriteString("data: ")
+	b.Write(e.data)
+	b.WriteString("\n\n")
+	_, err := w.Write([]byte(b.String()))
+	return err
+}
+
+// itemEvents returns events for items,
+// in reverse order.
+func itemEvents(items []jsonfeed.Item) []event {
+	var es []event
+	for i := len(items) - 1; i >= 0; i-- {
+		b, _ := json.Marshal(&items[i]) // valid, since the feed is
+		es = append(es, event{id: items[i].ID, data: b})
+	}
+	return es
+}
+
+// writeEvents writes es to w.
+func writeEvents(w http.ResponseWriter, es []event) error {
+	for _, e := range es {
+		if err := e.write(w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (h *EventHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		w.Header().Set("Allow", "GET")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	rc := http.NewResponseController(w)
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	if err := rc.Flush(); err != nil {
+		http.Error(w, "streaming not supported", http.StatusInternalServerError)
+		return
+	}
+	missed, updates, cancel := h.Publisher.subscribe(h.Buffer, r.Header.Get("Last-Event-ID"))
+	defer cancel()
+	if writeEvents(w, missed) != nil {
+		return
+	}
+	var heartbeat <-chan time.Time
+	if h.Heartbeat > 0 {
+		t := time.NewTicker(h.Heartbeat)
+		defer t.Stop()
+		heartbeat = t.C
+	}
+	for {
+		if rc.Flush() != nil {
+			return
+		}
+		select {
+		case <-r.Context().Done():
+			return
+		case u, ok := <-updates:
+			if !ok || writeEvents(w, itemEvents(u.Diff.Added)) != nil {
+				return
+			}
+		case <-heartbeat:
+			if _, err := w.Write([]byte(":\n\n")); err != nil {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,262 @@
+package serve
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kr/jsonfeed"
+)
+
+func itemsFeed(ids ...string) *jsonfeed.Feed {
+	f := &jsonfeed.Feed{Version: jsonfeed.Version, Title: "title"}
+	for _, id := range ids {
+		f.Items = append(f.Items, jsonfeed.Item{ID: id, ContentText: id})
+	}
+	return f
+}
+
+// waitClients waits for h to have n clients.
+func waitClients(t *testing.T, h *EventHandler, n int) {
+	t.Helper()
+	p := h.Publisher
+	for deadline := time.Now().Add(5 * time.Second); ; {
+		p.mu.Lock()
+		got := len(p.subs)
+		p.mu.Unlock()
+		if got == n {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("have %d clients, want %d", got, n)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// add adds items with the given IDs to p,
+// all in one change, in order,
+// so that the last one is listed first.
+func add(t *testing.T, p *Publisher, ids ...string) {
+	t.Helper()
+	err := p.change(func(f *jsonfeed.Feed) error {
+		f.Items = append(itemsFeed(ids...).Items, f.Items...)
+		slices.Reverse(f.Items[:len(ids)])
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// readEvent reads one event from r,
+// returning its ID and data.
+func readEvent(t *testing.T, r *bufio.Reader) (id, data string) {
+	t.Helper()
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			t.Fatalf("reading event: %v", err)
+		}
+		line = strings.TrimSuffix(line, "\n")
+		switch {
+		case line == "":
+			return id, data
+		case strings.HasPrefix(line, "id: "):
+			id = line[len("id: "):]
+		case strings.HasPrefix(line, "data: "):
+			data = line[len("data: "):]
+		}
+	}
+}
+
+func TestEventHandler(t *testing.T) {
+	p, _ := NewPublisher(itemsFeed("a"), 0)
+	h := &EventHandler{Publisher: p, Buffer: -1}
+	srv := httptest.NewServer(h)
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Errorf("Content-Type = %q, want text/event-stream", ct)
+	}
+	waitClients(t, h, 1)
+	add(t, p, "b", "c")
+	p.UpdateItem(jsonfeed.Item{ID: "a", ContentText: "a2"}) // not new
+	p.AddItem(jsonfeed.Item{ID: "d", ContentText: "d"})
+	r := bufio.NewReader(resp.Body)
+	for _, want := range []string{"b", "c", "d"} {
+		id, data := readEvent(t, r)
+		if id != want || !strings.Contains(data, `"content_text":"`+want+`"`) {
+			t.Errorf("event = %s %s, want item %s", id, data, want)
+		}
+	}
+
+	// A client that reconnects gets the events it missed,
+	// even for items no longer in the feed.
+	p.RemoveItem("b")
+	req, _ := http.NewRequest("GET", srv.URL, nil)
+	req.Header.Set("Last-Event-ID", "b")
+	resp2, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r2 := bufio.NewReader(resp2.Body)
+	for _, want := range []string{"c", "d"} {
+		if id, _ := readEvent(t, r2); id != want {
+			t.Errorf("resumed event = %s, want %s", id, want)
+		}
+	}
+	resp2.Body.Close()
+	resp.Body.Close()
+	waitClients(t, h, 0)
+}
+
+func TestEventWrite(t *testing.T) {
+	cases := []struct {
+		e    event
+		want string
+	}{
+		{event{"a", []byte("{}")}, "id: a\ndata: {}\n\n"},
+		{event{"a\nb", []byte("{}")}, "data: {}\n\n"},
+		{event{"", []byte("{}")}, "data: {}\n\n"},
+	}
+	for _, test := range cases {
+		w := httptest.NewRecorder()
+		test.e.write(w)
+		if got := w.Body.String(); got != test.want {
+			t.Errorf("write(%v) = %q, want %q", test.e, got, test.want)
+		}
+	}
+}
+
+// A fakeWriter is a ResponseWriter whose writes can block or fail.
+type fakeWriter struct {
+	mu      sync.Mutex
+	header  http.Header
+	writes  []string
+	err     error         // returned by Write
+	flushes int           // number of flushes that succeed
+	block   chan struct{} // if set, each Write waits to receive from it
+}
+
+func (w *fakeWriter) Header() http.Header { return w.header }
+func (w *fakeWriter) WriteHeader(int)     {}
+
+func (w *fakeWriter) Write(b []byte) (int, error) {
+	if w.block != nil {
+		<-w.block
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.writes = append(w.writes, string(b))
+	return len(b), w.err
+}
+
+func (w *fakeWriter) FlushError() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.flushes == 0 {
+		return errors.New("flush failed")
+	}
+	w.flushes--
+	return nil
+}
+
+// serve runs h.ServeHTTP(w, req) in the background
+// and returns a channel closed when it returns.
+func serve(h http.Handler, w http.ResponseWriter, req *http.Request) chan struct{} {
+	done := make(chan struct{})
+	go func() {
+		h.ServeHTTP(w, req)
+		close(done)
+	}()
+	return done
+}
+
+func wait(t *testing.T, done chan struct{}, what string) {
+	t.Helper()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("ServeHTTP did not return after %s", what)
+	}
+}
+
+func TestEventHandlerSlowClient(t *testing.T) {
+	p, _ := NewPublisher(itemsFeed(), 0)
+	h := &EventHandler{Publisher: p, Buffer: 1}
+	w := &fakeWriter{header: http.Header{}, flushes: 100, block: make(chan struct{})}
+	done := serve(h, w, httptest.NewRequest("GET", "/", nil))
+	waitClients(t, h, 1)
+	for _, id := range []string{"a", "b", "c", "d", "e"} {
+		p.AddItem(jsonfeed.Item{ID: id, ContentText: id})
+	}
+	waitClients(t, h, 0) // dropped
+	close(w.block)
+	wait(t, done, "drop")
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if n := len(w.writes); n == 0 || n > 2 {
+		t.Errorf("client got %d events, want 1 or 2", n)
+	}
+}
+
+func TestEventHandlerErrors(t *testing.T) {
+	p, _ := NewPublisher(itemsFeed("a"), 0)
+	h := &EventHandler{Publisher: p, Heartbeat: time.Millisecond}
+	fail := errors.New("write failed")
+
+	// Bad method.
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("POST", "/", nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("POST: code %d, want 405", rec.Code)
+	}
+
+	// No flushing.
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(struct{ http.ResponseWriter }{rec}, httptest.NewRequest("GET", "/", nil))
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("no flush: code %d, want 500", rec.Code)
+	}
+
+	// Write fails for a missed event.
+	req := httptest.NewRequest("GET", "/", nil)
+	resume := req.Clone(req.Context())
+	resume.Header.Set("Last-Event-ID", "a")
+	p.RemoveItem("a")
+	add(t, p, "a", "b")
+	wait(t, serve(h, &fakeWriter{header: http.Header{}, flushes: 1, err: fail}, resume), "missed write error")
+
+	// Flush fails in the loop.
+	wait(t, serve(h, &fakeWriter{header: http.Header{}, flushes: 1}, req), "flush error")
+
+	// Heartbeat write fails.
+	wait(t, serve(h, &fakeWriter{header: http.Header{}, flushes: 2, err: fail}, req), "heartbeat error")
+
+	// Event write fails.
+	h.Heartbeat = 0
+	done := serve(h, &fakeWriter{header: http.Header{}, flushes: 2, err: fail}, req)
+	waitClients(t, h, 1)
+	p.AddItem(jsonfeed.Item{ID: "c", ContentText: "c"})
+	wait(t, done, "event write error")
+
+	// Client goes away.
+	ctx, cancel := context.WithCancel(context.Background())
+	done = serve(h, &fakeWriter{header: http.Header{}, flushes: 2}, req.WithContext(ctx))
+	waitClients(t, h, 1)
+	cancel()
+	wait(t, done, "cancel")
+}
@@ -0,0 +1,217 @@
+package serve
+
+import (
+	"errors"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/kr/jsonfeed"
+)
+
+// ErrNoItem is returned by UpdateItem and RemoveItem
+// when the feed has no item with the given ID.
+var ErrNoItem = errors.New("serve: no item with that id")
+
+// A Publisher holds a feed that changes as the program runs.
+// It is a Source, so it can be served by a Handler,
+// and readers always get a complete version of the feed,
+// however it is being changed.
+//
+// Each change makes a new Version,
+// which must be valid.
+// A change that would make the feed invalid
+// returns an error and leaves the feed as it was.
+//
+// A Publisher is safe for use by multiple goroutines.
+type Publisher struct {
+	max int
+	cur atomic.Pointer[Version]
+
+	mu      sync.Mutex // serializes changes
+	subs    map[chan *Update]bool
+	history []event // for the last History items added, oldest first
+}
+
+// An Update describes a change to a Publisher's feed.
+type Update struct {
+	Version *Version           // the new version
+	Diff    *jsonfeed.FeedDiff // changes from the previous version
+}
+
+// NewPublisher returns a Publisher for feed f.
+// If maxItems is positive, the feed keeps at most that many items,
+// dropping items from the end, where the oldest are.
+// It returns an error if f is not valid.
+func NewPublisher(f *jsonfeed.Feed, maxItems int) (*Publisher, error) {
+	p := &Publisher{max: maxItems, subs: make(map[chan *Update]bool)}
+	f = f.Filter(nil)
+	p.trim(f)
+	v, err := newVersion(f, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	p.cur.Store(v)
+	return p, nil
+}
+
+// trim drops items from f beyond p's limit.
+func (p *Publisher) trim(f *jsonfeed.Feed) {
+	if p.max > 0 && len(f.Items) > p.max {
+		f.Items = f.Items[:p.max]
+	}
+}
+
+// Current returns the current version of the feed.
+func (p *Publisher) Current() *Version {
+	return p.cur.Load()
+}
+
+// change applies fn to a copy of the current feed
+// and publishes the result.
+func (p *Publisher) change(fn func(f *jsonfeed.Feed) error) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	old := p.cur.Load()
+	f := old.Feed.Filter(nil)
+	if err := fn(f); err != nil {
+		return err
+	}
+	p.trim(f)
+	v, err := newVersion(f, time.Now())
+	if err != nil {
+		return err
+	}
+	p.cur.Store(v)
+	u := &Update{Version: v, Diff: jsonfeed.Diff(old.Feed, v.Feed)}
+	p.history = append(p.history, itemEvents(u.Diff.Added)...)
+	if n := len(p.history) - History; n > 0 {
+		p.history = slices.Delete(p.history, 0, n)
+	}
+	for c := range p.subs {
+		select {
+		case c <- u:
+		default:
+			close(c) // too slow; drop it
+			delete(p.subs, c)
+		}
+	}
+	return nil
+}
+
+// index returns the index of the item in f with the given ID,
+// or -1 if there is none.
+func index(f *jsonfeed.Feed, id string) int {
+	for i := range f.Items {
+		if f.Items[i].ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// copyItem returns a copy of t
+// that shares no slices or maps with it,
+// so the caller may go on changing t.
+func copyItem(t *jsonfeed.Item) jsonfeed.Item {
+	f := &jsonfeed.Feed{Items: []jsonfeed.Item{*t}}
+	return f.Filter(nil).Items[0]
+}
+
+// AddItem adds a copy of t to the start of the feed,
+// where the newest items go.
+// It returns an error if the feed already has an item
+// with the same ID.
+func (p *Publisher) AddItem(t jsonfeed.Item) error {
+	t = copyItem(&t)
+	return p.change(func(f *jsonfeed.Feed) error {
+		if index(f, t.ID) >= 0 {
+			return errors.New("serve: duplicate item id " + t.ID)
+		}
+		f.Items = append([]jsonfeed.Item{t}, f.Items...)
+		return nil
+	})
+}
+
+// UpdateItem replaces the item with the same ID as t
+// by a copy of t.
+// It returns ErrNoItem if there is none.
+func (p *Publisher) UpdateItem(t jsonfeed.Item) error {
+	t = copyItem(&t)
+	return p.change(func(f *jsonfeed.Feed) error {
+		i := index(f, t.ID)
+		if i < 0 {
+			return ErrNoItem
+		}
+		f.Items[i] = t
+		return nil
+	})
+}
+
+// RemoveItem removes the item with the given ID.
+// It returns ErrNoItem if there is none.
+func (p *Publisher) RemoveItem(id string) error {
+	return p.change(func(f *jsonfeed.Feed) error {
+		i := index(f, id)
+		if i < 0 {
+			return ErrNoItem
+		}
+		f.Items = append(f.Items[:i], f.Items[i+1:]...)
+		return nil
+	})
+}
+
+// Subscribe returns a channel that receives an Update
+// for each change to the feed,
+// and a function that cancels the subscription
+// and closes the channel.
+// The channel buffers the given number of Updates,
+// or DefaultBuffer if buffer is not positive;
+// if a subscriber falls further behind,
+// its channel is closed.
+// To follow the feed without missing changes,
+// a subscriber whose channel is closed can subscribe again
+// and start from the Current version.
+func (p *Publisher) Subscribe(buffer int) (<-chan *Update, func()) {
+	_, c, cancel := p.subscribe(buffer, "")
+	return c, cancel
+}
+
+// subscribe is like Subscribe,
+// but it also returns the events for the items added
+// after the one with ID lastID,
+// if that is in p's history.
+func (p *Publisher) subscribe(buffer int, lastID string) ([]event, <-chan *Update, func()) {
+	if buffer <= 0 {
+		buffer = DefaultBuffer
+	}
+	c := make(chan *Update, buffer)
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.subs[c] = true
+	return p.since(lastID), c, func() {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		if p.subs[c] {
+			delete(p.subs, c)
+			close(c)
+		}
+	}
+}
+
+// since returns the events in p's history
+// after the one with ID lastID,
+// or nil if there is none.
+// p.mu must be held.
+func (p *Publisher) since(lastID string) []event {
+	if lastID == "" {
+		return nil
+	}
+	for i := len(p.history) - 1; i >= 0; i-- {
+		if p.history[i].id == lastID {
+			return slices.Clone(p.history[i+1:])
+		}
+	}
+	return nil
+}
@@ -0,0 +1,219 @@
+package serve
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/kr/jsonfeed"
+)
+
+func ids(f *jsonfeed.Feed) []string {
+	var a []string
+	for _, t := range f.Items {
+		a = append(a, t.ID)
+	}
+	return a
+}
+
+func TestPublisher(t *testing.T) {
+	if _, err := NewPublisher(&jsonfeed.Feed{}, 0); err == nil {
+		t.Errorf("NewPublisher(invalid) = nil, want error")
+	}
+	f := itemsFeed("c", "b", "a")
+	p, err := NewPublisher(f, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Items[0].ContentText = "changed"
+	v0 := p.Current()
+	if v0.Feed.Items[0].ContentText != "c" {
+		t.Errorf("Publisher shares items with its feed")
+	}
+	updates, cancel := p.Subscribe(10)
+
+	steps := []struct {
+		name string
+		op   func() error
+		want []string
+		ok   bool
+	}{
+		{"add d", func() error { return p.AddItem(jsonfeed.Item{ID: "d", ContentText: "d"}) }, []string{"d", "c", "b"}, true},
+		{"add dup", func() error { return p.AddItem(jsonfeed.Item{ID: "c", ContentText: "c"}) }, nil, false},
+		{"add invalid", func() error { return p.AddItem(jsonfeed.Item{ID: "e"}) }, nil, false},
+		{"update c", func() error { return p.UpdateItem(jsonfeed.Item{ID: "c", ContentText: "c2"}) }, []string{"d", "c", "b"}, true},
+		{"update none", func() error { return p.UpdateItem(jsonfeed.Item{ID: "x", ContentText: "x"}) }, nil, false},
+		{"update invalid", func() error { return p.UpdateItem(jsonfeed.Item{ID: "c"}) }, nil, false},
+		{"remove b", func() error { return p.RemoveItem("b") }, []string{"d", "c"}, true},
+		{"remove none", func() error { return p.RemoveItem("b") }, nil, false},
+	}
+	for _, step := range steps {
+		before := p.Current()
+		err := step.op()
+		if (err == nil) != step.ok {
+			t.Errorf("%s: err = %v, want ok %v", step.name, err, step.ok)
+			continue
+		}
+		if !step.ok {
+			if p.Current() != before {
+				t.Errorf("%s: failed change made a new version", step.name)
+			}
+			continue
+		}
+		if got := ids(p.Current().Feed); !reflect.DeepEqual(got, step.want) {
+			t.Errorf("%s: ids = %q, want %q", step.name, got, step.want)
+		}
+		u := <-updates
+		if u.Version != p.Current() || u.Diff.Empty() {
+			t.Errorf("%s: update = %+v", step.name, u)
+		}
+	}
+	if p.RemoveItem("x") != ErrNoItem || p.UpdateItem(jsonfeed.Item{ID: "x"}) != ErrNoItem {
+		t.Errorf("want ErrNoItem for missing items")
+	}
+
+	cancel()
+	cancel() // no effect
+	if _, ok := <-updates; ok {
+		t.Errorf("update after cancel")
+	}
+}
+
+func TestPublisherCopiesItems(t *testing.T) {
+	p, _ := NewPublisher(itemsFeed(), 0)
+	added := jsonfeed.Item{ID: "a", ContentText: "a", Tags: []string{"x"}, Author: &jsonfeed.Author{Name: "kr"}}
+	p.AddItem(added)
+	p.AddItem(jsonfeed.Item{ID: "b", ContentText: "b"})
+	updated := jsonfeed.Item{ID: "b", ContentText: "b", Attachments: []jsonfeed.Attachment{{URL: "u", MIMEType: "text/plain"}}}
+	p.UpdateItem(updated)
+	v := p.Current()
+	added.Tags[0] = "changed"
+	added.Author.Name = "changed"
+	updated.Attachments[0].URL = "changed"
+	if b, _ := json.Marshal(v.Feed); string(b) != string(v.JSON) {
+		t.Errorf("changing items after adding them changed the published version:\n%s\nwant:\n%s", b, v.JSON)
+	}
+}
+
+func TestPublisherSlowSubscriber(t *testing.T) {
+	p, _ := NewPublisher(itemsFeed(), 2)
+	slow, _ := p.Subscribe(1)
+	fast, cancel := p.Subscribe(10)
+	defer cancel()
+	for _, id := range []string{"a", "b", "c"} {
+		if err := p.AddItem(jsonfeed.Item{ID: id, ContentText: id}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	n := 0
+	for range slow {
+		n++
+	}
+	if n != 1 {
+		t.Errorf("slow subscriber got %d updates, want 1", n)
+	}
+	for i := 0; i < 3; i++ {
+		<-fast
+	}
+	if got, want := ids(p.Current().Feed), []string{"c", "b"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("ids = %q, want %q", got, want)
+	}
+}
+
+func TestPublisherDefaultBuffer(t *testing.T) {
+	p, _ := NewPublisher(itemsFeed(), 0)
+	for _, buffer := range []int{0, -1} {
+		updates, cancel := p.Subscribe(buffer)
+		if n := cap(updates); n != DefaultBuffer {
+			t.Errorf("Subscribe(%d) buffers %d updates, want %d", buffer, n, DefaultBuffer)
+		}
+		p.AddItem(jsonfeed.Item{ID: strconv.Itoa(buffer), ContentText: "x"})
+		if _, ok := <-updates; !ok {
+			t.Errorf("Subscribe(%d) dropped on the first change", buffer)
+		}
+		cancel()
+	}
+}
+
+func TestPublisherHistory(t *testing.T) {
+	p, _ := NewPublisher(itemsFeed("a"), 2)
+	add(t, p, "b", "c")
+	add(t, p, "d")
+	p.RemoveItem("c")
+	cases := []struct {
+		lastID string
+		want   []string
+	}{
+		{"", nil},
+		{"a", nil}, // never added
+		{"x", nil},
+		{"b", []string{"c", "d"}}, // trimmed
+		{"c", []string{"d"}},      // removed
+		{"d", nil},
+	}
+	for _, test := range cases {
+		var got []string
+		for _, e := range p.since(test.lastID) {
+			got = append(got, e.id)
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("since(%q) = %q, want %q", test.lastID, got, test.want)
+		}
+	}
+
+	for i := range History {
+		p.AddItem(jsonfeed.Item{ID: strconv.Itoa(i), ContentText: "x"})
+	}
+	if got := p.since("d"); got != nil {
+		t.Errorf("since(d) after %d more items = %v, want nil", History, got)
+	}
+	if got := p.since("0"); len(got) != History-1 {
+		t.Errorf("since(0) = %d events, want %d", len(got), History-1)
+	}
+}
+
+func TestPublisherServe(t *testing.T) {
+	p, _ := NewPublisher(itemsFeed("a"), 0)
+	events := &EventHandler{Publisher: p}
+	srv := httptest.NewServer(events)
+	defer srv.Close()
+	resp, err := http.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	waitClients(t, events, 1)
+
+	h := &Handler{Source: p}
+	var wg sync.WaitGroup
+	for i := range 10 {
+		wg.Go(func() {
+			p.AddItem(jsonfeed.Item{ID: string(rune('b' + i)), ContentText: "x"})
+		})
+		wg.Go(func() {
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+			if w.Code != http.StatusOK || w.Header().Get("ETag") == "" {
+				t.Errorf("GET: code %d, headers %v", w.Code, w.Header())
+			}
+		})
+	}
+	wg.Wait()
+	if got := len(p.Current().Feed.Items); got != 11 {
+		t.Errorf("have %d items, want 11", got)
+	}
+	r := bufio.NewReader(resp.Body)
+	seen := make(map[string]bool)
+	for range 10 {
+		id, _ := readEvent(t, r)
+		seen[id] = true
+	}
+	if len(seen) != 10 {
+		t.Errorf("streamed %d distinct items, want 10", len(seen))
+	}
+}
@@ -0,0 +1,116 @@
+/*
+Package serve serves JSON feeds over HTTP.
+
+A Handler serves the current Version of a feed from a Source,
+with the caching headers and conditional request support
+that let readers poll it cheaply.
+A Publisher is a Source for a feed that changes as the program runs.
+An EventHandler streams the items added to a Publisher's feed
+to clients that would rather not poll.
+*/
+package serve
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/kr/jsonfeed"
+)
+
+// ContentType is the media type of a JSON feed.
+const ContentType = "application/feed+json; charset=utf-8"
+
+// ExpiredMaxAge is the cache lifetime sent for an expired feed.
+// Since an expired feed never changes,
+// caches may keep it about as long as HTTP allows.
+const ExpiredMaxAge = 365 * 24 * time.Hour
+
+// A Version is one version of a feed, encoded for serving.
+// It must not be modified.
+type Version struct {
+	Feed     *jsonfeed.Feed
+	JSON     []byte
+	ETag     string    // a strong validator for JSON
+	Modified time.Time // zero if unknown
+}
+
+// NewVersion encodes a copy of f, last modified at the given time,
+// for serving.
+// It returns an error if f is not valid.
+func NewVersion(f *jsonfeed.Feed, modified time.Time) (*Version, error) {
+	return newVersion(f.Filter(nil), modified) // copy, so the caller may go on changing f
+}
+
+// newVersion is like NewVersion, but does not copy f.
+func newVersion(f *jsonfeed.Feed, modified time.Time) (*Version, error) {
+	b, err := json.Marshal(f)
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(b)
+	return &Version{
+		Feed:     f,
+		JSON:     b,
+		ETag:     `"` + hex.EncodeToString(sum[:16]) + `"`,
+		Modified: modified,
+	}, nil
+}
+
+// Current returns v.
+// It makes a Version a Source that never changes.
+func (v *Version) Current() *Version {
+	return v
+}
+
+// A Source provides the version of a feed to serve.
+type Source interface {
+	// Current returns the current version of the feed,
+	// or nil if there is none yet.
+	Current() *Version
+}
+
+// A Handler serves a feed.
+// It answers GET and HEAD requests with the current version
+// from Source, supporting conditional and range requests.
+// If there is no current version, it responds with status 503.
+type Handler struct {
+	Source Source
+
+	// MaxAge is how long caches may keep the feed.
+	// If zero, caches must check with the server every time.
+	// Expired feeds are kept for ExpiredMaxAge instead.
+	MaxAge time.Duration
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" && r.Method != "HEAD" {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	v := h.Source.Current()
+	if v == nil {
+		http.Error(w, "feed not available", http.StatusServiceUnavailable)
+		return
+	}
+	w.Header().Set("Content-Type", ContentType)
+	w.Header().Set("ETag", v.ETag)
+	w.Header().Set("Cache-Control", h.cacheControl(v.Feed))
+	http.ServeContent(w, r, "", v.Modified, bytes.NewReader(v.JSON))
+}
+
+// cacheControl returns the Cache-Control header for serving f.
+func (h *Handler) cacheControl(f *jsonfeed.Feed) string {
+	switch {
+	case f.Expired:
+		return "public, max-age=" + strconv.Itoa(int(ExpiredMaxAge/time.Second)) + ", immutable"
+	case h.MaxAge > 0:
+		return "public, max-age=" + strconv.Itoa(int(h.MaxAge/time.Second))
+	}
+	return "no-cache"
+}
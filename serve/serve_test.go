@@ -0,0 +1,91 @@
+package serve
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kr/jsonfeed"
+)
+
+func testFeed() *jsonfeed.Feed {
+	return &jsonfeed.Feed{
+		Version: jsonfeed.Version,
+		Title:   "title",
+		Items:   []jsonfeed.Item{{ID: "a", ContentText: "a"}},
+	}
+}
+
+func TestNewVersion(t *testing.T) {
+	f := testFeed()
+	v, err := NewVersion(f, time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Items[0].ContentText = "changed"
+	if v.Feed.Items[0].ContentText != "a" {
+		t.Errorf("Version shares items with its feed")
+	}
+	v1, _ := NewVersion(f, time.Time{})
+	if v1.ETag == v.ETag || len(v.ETag) != 34 {
+		t.Errorf("ETags = %s, %s, want different", v.ETag, v1.ETag)
+	}
+	if _, err := NewVersion(&jsonfeed.Feed{}, time.Time{}); err == nil {
+		t.Errorf("NewVersion(invalid) = nil, want error")
+	}
+}
+
+type nilSource struct{}
+
+func (nilSource) Current() *Version { return nil }
+
+func TestHandler(t *testing.T) {
+	mod := time.Date(2017, 5, 17, 10, 0, 0, 0, time.UTC)
+	live, _ := NewVersion(testFeed(), mod)
+	f := testFeed()
+	f.Expired = true
+	expired, _ := NewVersion(f, time.Time{})
+
+	cases := []struct {
+		h       *Handler
+		method  string
+		header  string // request header: name: value
+		code    int
+		cc      string
+		hasBody bool
+	}{
+		{&Handler{Source: live}, "GET", "", 200, "no-cache", true},
+		{&Handler{Source: live, MaxAge: time.Hour}, "GET", "", 200, "public, max-age=3600", true},
+		{&Handler{Source: expired, MaxAge: time.Hour}, "GET", "", 200, "public, max-age=31536000, immutable", true},
+		{&Handler{Source: live}, "HEAD", "", 200, "no-cache", false},
+		{&Handler{Source: live}, "GET", "If-None-Match: " + live.ETag, 304, "no-cache", false},
+		{&Handler{Source: live}, "GET", "If-Modified-Since: " + mod.Format(http.TimeFormat), 304, "no-cache", false},
+		{&Handler{Source: live}, "GET", "Range: bytes=0-0", 206, "no-cache", true},
+		{&Handler{Source: live}, "POST", "", 405, "", true},
+		{&Handler{Source: nilSource{}}, "GET", "", 503, "", true},
+	}
+	for _, test := range cases {
+		req := httptest.NewRequest(test.method, "/feed.json", nil)
+		if test.header != "" {
+			k, v, _ := strings.Cut(test.header, ": ")
+			req.Header.Set(k, v)
+		}
+		w := httptest.NewRecorder()
+		test.h.ServeHTTP(w, req)
+		if w.Code != test.code || w.Header().Get("Cache-Control") != test.cc || (w.Body.Len() > 0) != test.hasBody {
+			t.Errorf("%s %s: code %d, Cache-Control %q, body %q", test.method, test.header, w.Code, w.Header().Get("Cache-Control"), w.Body)
+		}
+	}
+
+	w := httptest.NewRecorder()
+	(&Handler{Source: live}).ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+	h := w.Header()
+	if h.Get("Content-Type") != ContentType || h.Get("ETag") != live.ETag || h.Get("Last-Modified") != mod.Format(http.TimeFormat) {
+		t.Errorf("headers = %v", h)
+	}
+	if w.Body.String() != string(live.JSON) {
+		t.Errorf("body = %s, want %s", w.Body, live.JSON)
+	}
+}
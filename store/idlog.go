@@ -0,0 +1,119 @@
+/*
+Package store keeps the state of JSON Feed
+publishers and readers in files.
+
+An IDLog records the item IDs a feed has used,
+for jsonfeed.CheckIDs.
+LoadReaderState and SaveReaderState keep
+a jsonfeed.ReaderState between runs.
+*/
+package store
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"strconv"
+	"sync"
+
+	"github.com/kr/jsonfeed"
+)
+
+// An IDLog is a jsonfeed.IDStore kept in a file
+// as an append-only log of jsonfeed.IDRecords in JSON, one per line.
+// Later lines replace earlier ones with the same ID.
+// An incomplete last line, as left by a crash
+// in the middle of writing, is ignored.
+//
+// An IDLog is safe for use by multiple goroutines,
+// but not by multiple processes at once.
+type IDLog struct {
+	name string
+	mu   sync.Mutex
+}
+
+// NewIDLog returns an IDLog in the named file.
+// The file is created when records are first stored.
+func NewIDLog(name string) *IDLog {
+	return &IDLog{name: name}
+}
+
+// Load reads the log.
+// It returns no records if the file does not exist.
+func (l *IDLog) Load() (map[string]jsonfeed.IDRecord, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	b, err := os.ReadFile(l.name)
+	if errors.Is(err, fs.ErrNotExist) {
+		return map[string]jsonfeed.IDRecord{}, nil
+	} else if err != nil {
+		return nil, err
+	}
+	recs := make(map[string]jsonfeed.IDRecord)
+	if i := bytes.LastIndexByte(b, '\n'); i < len(b)-1 {
+		b = b[:i+1] // drop incomplete last line
+	}
+	sc := bufio.NewScanner(bytes.NewReader(b))
+	sc.Buffer(nil, len(b)+1)
+	for line := 1; sc.Scan(); line++ {
+		var r jsonfeed.IDRecord
+		if err := json.Unmarshal(sc.Bytes(), &r); err != nil {
+			return nil, errors.New("store: " + l.name + ":" + strconv.Itoa(line) + ": " + err.Error())
+		}
+		recs[r.ID] = r
+	}
+	return recs, nil
+}
+
+// Store appends recs to the log
+// and waits for them to reach stable storage.
+// It first removes any incomplete last line,
+// so the new records start on a line of their own.
+func (l *IDLog) Store(recs []jsonfeed.IDRecord) error {
+	var buf bytes.Buffer
+	for _, r := range recs {
+		b, _ := json.Marshal(&r) // IDRecords always encode
+		buf.Write(b)
+		buf.WriteByte('\n')
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	f, err := os.OpenFile(l.name, os.O_RDWR|os.O_CREATE, 0o666)
+	if err != nil {
+		return err
+	}
+	end, err := completeLines(f)
+	if err == nil {
+		err = f.Truncate(end)
+	}
+	if err == nil {
+		_, err = f.WriteAt(buf.Bytes(), end)
+	}
+	if err == nil {
+		err = f.Sync()
+	}
+	if err1 := f.Close(); err == nil {
+		err = err1
+	}
+	return err
+}
+
+// completeLines returns the length of f
+// up to the end of its last complete line.
+func completeLines(f *os.File) (int64, error) {
+	end, err := f.Seek(0, io.SeekEnd)
+	buf := make([]byte, 4096)
+	for err == nil && end > 0 {
+		n := min(end, int64(len(buf)))
+		end -= n
+		_, err = f.ReadAt(buf[:n], end)
+		if i := bytes.LastIndexByte(buf[:n], '\n'); err == nil && i >= 0 {
+			return end + int64(i) + 1, nil
+		}
+	}
+	return end, err
+}
@@ -0,0 +1,91 @@
+package store
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/kr/jsonfeed"
+)
+
+func TestIDLog(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "ids.log")
+	l := NewIDLog(name)
+	recs, err := l.Load()
+	if err != nil || len(recs) != 0 {
+		t.Fatalf("Load(missing file) = %v, %v, want empty", recs, err)
+	}
+	d := time.Date(2017, 5, 17, 0, 0, 0, 0, time.UTC)
+	f := &jsonfeed.Feed{Items: []jsonfeed.Item{{ID: "a", ContentText: "a"}, {ID: "b", ContentText: "b"}}}
+	if err := jsonfeed.RecordIDs(l, f, d); err != nil {
+		t.Fatal(err)
+	}
+	if err := jsonfeed.RecordIDs(l, &jsonfeed.Feed{Items: f.Items[1:]}, d); err != nil {
+		t.Fatal(err)
+	}
+	// Simulate a crash in the middle of a write.
+	fd, _ := os.OpenFile(name, os.O_WRONLY|os.O_APPEND, 0)
+	fd.WriteString(`{"id":"c","has`)
+	fd.Close()
+
+	recs, err = l.Load()
+	if err != nil {
+		t.Fatalf("Load() = %v, want nil", err)
+	}
+	hashes := map[string]string{}
+	for id, r := range recs {
+		hashes[id] = r.Hash
+	}
+	if hashes["a"] == "" || hashes["a"] == hashes["b"] {
+		t.Errorf("Load() hashes = %q, want distinct hashes", hashes)
+	}
+	want := map[string]jsonfeed.IDRecord{
+		"a": {ID: "a", Hash: hashes["a"], FirstSeen: d},
+		"b": {ID: "b", Hash: hashes["b"], Present: true, FirstSeen: d},
+	}
+	if !reflect.DeepEqual(recs, want) {
+		t.Errorf("Load() = %+v, want %+v", recs, want)
+	}
+
+	// Storing after a crash replaces the incomplete line.
+	if err := jsonfeed.RecordIDs(l, &jsonfeed.Feed{Items: f.Items[:1]}, d); err != nil {
+		t.Fatal(err)
+	}
+	recs, err = l.Load()
+	if err != nil {
+		t.Fatalf("Load() after crash and Store = %v, want nil", err)
+	}
+	if r := recs["a"]; !r.Present || recs["b"].Present || len(recs) != 2 {
+		t.Errorf("Load() after crash and Store = %+v, want a present, b removed", recs)
+	}
+
+	// An incomplete line longer than the read buffer,
+	// with no complete line before it, is removed too.
+	os.WriteFile(name, bytes.Repeat([]byte("x"), 5000), 0o666)
+	if err := l.Store([]jsonfeed.IDRecord{{ID: "c"}}); err != nil {
+		t.Fatal(err)
+	}
+	if recs, err := l.Load(); err != nil || len(recs) != 1 || recs["c"].ID != "c" {
+		t.Errorf("Load() after long partial line = %v, %v, want c", recs, err)
+	}
+
+	os.WriteFile(name, []byte("{}\nnot json\n"), 0o666)
+	if _, err := l.Load(); err == nil || err.Error() != "store: "+name+":2: invalid character 'o' in literal null (expecting 'u')" {
+		t.Errorf("Load(bad line) = %v, want error on line 2", err)
+	}
+	os.WriteFile(name, []byte("partial"), 0o666)
+	if recs, err := l.Load(); err != nil || len(recs) != 0 {
+		t.Errorf("Load(partial line) = %v, %v, want empty", recs, err)
+	}
+
+	dir := NewIDLog(t.TempDir())
+	if _, err := dir.Load(); err == nil {
+		t.Errorf("Load(directory) = nil, want error")
+	}
+	if err := dir.Store([]jsonfeed.IDRecord{{ID: "a"}}); err == nil {
+		t.Errorf("Store(directory) = nil, want error")
+	}
+}
@@ -0,0 +1,53 @@
+package store
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/kr/jsonfeed"
+)
+
+// LoadReaderState reads a jsonfeed.ReaderState from the named file,
+// as written by SaveReaderState.
+// It returns an empty state if the file does not exist.
+func LoadReaderState(name string) (*jsonfeed.ReaderState, error) {
+	s := new(jsonfeed.ReaderState)
+	b, err := os.ReadFile(name)
+	if errors.Is(err, fs.ErrNotExist) {
+		return s, nil
+	} else if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(b, s); err != nil {
+		return nil, errors.New("store: " + name + ": " + err.Error())
+	}
+	return s, nil
+}
+
+// SaveReaderState writes s to the named file in JSON.
+// It replaces the file atomically,
+// so a crash while saving leaves the old state intact.
+func SaveReaderState(name string, s *jsonfeed.ReaderState) error {
+	b, _ := s.MarshalJSON() // ReaderStates always encode
+	f, err := os.CreateTemp(filepath.Dir(name), filepath.Base(name)+".tmp-")
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(b)
+	if err == nil {
+		err = f.Sync()
+	}
+	if err1 := f.Close(); err == nil {
+		err = err1
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), name)
+	}
+	if err != nil {
+		os.Remove(f.Name())
+	}
+	return err
+}
@@ -0,0 +1,55 @@
+package store
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/kr/jsonfeed"
+)
+
+func TestReaderStateSave(t *testing.T) {
+	dir := t.TempDir()
+	name := filepath.Join(dir, "state.json")
+	s, err := LoadReaderState(name)
+	if err != nil {
+		t.Fatalf("LoadReaderState(missing) = %v", err)
+	}
+	if b, _ := s.MarshalJSON(); string(b) != `{"feeds":{}}` {
+		t.Errorf("MarshalJSON(empty) = %s", b)
+	}
+
+	d := time.Date(2017, 5, 17, 0, 0, 0, 0, time.UTC)
+	s.Resurface = true
+	s.MarkRead("f", &jsonfeed.Item{ID: "a", ContentText: "a", DateModified: d}, d)
+	s.SetStarred("f", "b", true)
+	if err := SaveReaderState(name, s); err != nil {
+		t.Fatalf("SaveReaderState() = %v", err)
+	}
+	s1, err := LoadReaderState(name)
+	if err != nil {
+		t.Fatalf("LoadReaderState() = %v", err)
+	}
+	got, _ := s1.MarshalJSON()
+	want, _ := s.MarshalJSON()
+	if !s1.Resurface || string(got) != string(want) {
+		t.Errorf("LoadReaderState() = %s, want %s", got, want)
+	}
+
+	for _, in := range []string{`[]`, `{"feeds":{"f":{"a":null}}}`} {
+		os.WriteFile(name, []byte(in), 0o666)
+		if _, err := LoadReaderState(name); err == nil {
+			t.Errorf("LoadReaderState(%s) = nil, want error", in)
+		}
+	}
+	if _, err := LoadReaderState(dir); err == nil {
+		t.Errorf("LoadReaderState(dir) = nil, want error")
+	}
+	if err := SaveReaderState(filepath.Join(dir, "none", "state.json"), s); err == nil {
+		t.Errorf("SaveReaderState(missing dir) = nil, want error")
+	}
+	if err := SaveReaderState(dir, s); err == nil {
+		t.Errorf("SaveReaderState(onto dir) = nil, want error")
+	}
+}
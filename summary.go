@@ -0,0 +1,195 @@
+package jsonfeed
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// DefaultSummaryLen is the maximum length, in characters,
+// of summaries made by a Summarizer with MaxLen unset.
+const DefaultSummaryLen = 200
+
+// EllipsisPolicy says when a Summarizer
+// marks a summary as shortened.
+type EllipsisPolicy int
+
+const (
+	// EllipsisIfCut appends the ellipsis only if the summary
+	// ends in the middle of a sentence.
+	EllipsisIfCut EllipsisPolicy = iota
+
+	// EllipsisIfShortened appends the ellipsis whenever
+	// the summary omits any of the text,
+	// even if it ends at a sentence boundary.
+	EllipsisIfShortened
+
+	// EllipsisNever never appends the ellipsis.
+	EllipsisNever
+)
+
+// A Summarizer produces short plain text summaries
+// suitable for Item.Summary.
+// It prefers to end a summary at a sentence boundary,
+// then at a word boundary,
+// and never splits a multi-byte character.
+// The zero value is ready to use.
+type Summarizer struct {
+	// MaxLen is the maximum length of a summary in characters
+	// (Unicode code points), including any ellipsis.
+	// If MaxLen is zero, DefaultSummaryLen is used.
+	MaxLen int
+
+	// MaxSentences is the maximum number of whole sentences
+	// in a summary. If MaxSentences is zero, 2 is used.
+	MaxSentences int
+
+	// Ellipsis is appended to shortened summaries,
+	// according to Policy.
+	// If Ellipsis is empty, "…" is used.
+	Ellipsis string
+
+	// Policy says when to append Ellipsis.
+	Policy EllipsisPolicy
+}
+
+// Summarize returns a summary of text
+// no longer than maxLen characters,
+// using the default settings of Summarizer.
+func Summarize(text string, maxLen int) string {
+	s := &Summarizer{MaxLen: maxLen}
+	return s.Summarize(text)
+}
+
+// Summarize returns a summary of the plain text in text.
+// Runs of white space, including line breaks,
+// are collapsed to a single space.
+func (s *Summarizer) Summarize(text string) string {
+	text = strings.Join(strings.Fields(text), " ")
+	if text == "" {
+		return ""
+	}
+	maxLen, maxSentences, ellipsis := s.MaxLen, s.MaxSentences, s.Ellipsis
+	if maxLen <= 0 {
+		maxLen = DefaultSummaryLen
+	}
+	if maxSentences <= 0 {
+		maxSentences = 2
+	}
+	if ellipsis == "" {
+		ellipsis = "…"
+	}
+	if s.Policy == EllipsisNever {
+		ellipsis = ""
+	}
+
+	// Take as many whole sentences as fit.
+	var n int // length of summary in bytes
+	for i, end := range sentenceEnds(text) {
+		if i == maxSentences {
+			break
+		}
+		room := maxLen
+		if end < len(text) && s.Policy == EllipsisIfShortened {
+			room -= utf8.RuneCountInString(" " + ellipsis)
+		}
+		if utf8.RuneCountInString(text[:end]) > room {
+			break
+		}
+		n = end
+	}
+	if n == len(text) {
+		return text
+	}
+	if n > 0 {
+		if s.Policy == EllipsisIfShortened && ellipsis != "" {
+			return text[:n] + " " + ellipsis
+		}
+		return text[:n]
+	}
+
+	// The first sentence is too long; cut it at a word boundary.
+	room := maxLen - utf8.RuneCountInString(ellipsis)
+	if room <= 0 {
+		return truncateRunes(ellipsis, maxLen)
+	}
+	cut := truncateRunes(text, room)
+	if i := strings.LastIndexByte(cut, ' '); i > 0 && text[len(cut)] != ' ' {
+		cut = cut[:i]
+	}
+	cut = strings.TrimRightFunc(cut, func(r rune) bool {
+		return unicode.IsSpace(r) || unicode.IsPunct(r) && !strings.ContainsRune(")]\"'”’", r)
+	})
+	return cut + ellipsis
+}
+
+// Summary returns a summary of t's content,
+// taken from ContentText if present, or else ContentHTML.
+func (s *Summarizer) Summary(t *Item) string {
+	text := t.ContentText
+	if text == "" {
+		text = htmlToText(t.ContentHTML, false)
+	}
+	return s.Summarize(text)
+}
+
+// Fill sets Summary for each item in f that lacks one.
+func (s *Summarizer) Fill(f *Feed) {
+	for i := range f.Items {
+		t := &f.Items[i]
+		if t.Summary == "" {
+			t.Summary = s.Summary(t)
+		}
+	}
+}
+
+// sentenceEnds returns the byte offsets in text
+// just past the end of each sentence.
+// A sentence ends with terminal punctuation,
+// optionally followed by closing quotes or brackets,
+// and then a space or the end of text.
+// Full-width terminals, as used in CJK text,
+// need not be followed by a space.
+// The last offset is always len(text).
+func sentenceEnds(text string) []int {
+	var ends []int
+	for i, r := range text {
+		if !isSentenceTerminal(r) {
+			continue
+		}
+		j := i + utf8.RuneLen(r)
+		for j < len(text) {
+			c, size := utf8.DecodeRuneInString(text[j:])
+			if !isSentenceTerminal(c) && !strings.ContainsRune(")]\"'”’»", c) {
+				break
+			}
+			j += size
+		}
+		if j < len(text) && text[j] != ' ' && r < utf8.RuneSelf {
+			continue // e.g. "3.14" or "example.org"
+		}
+		if len(ends) == 0 || ends[len(ends)-1] < j {
+			ends = append(ends, j)
+		}
+	}
+	if len(ends) == 0 || ends[len(ends)-1] != len(text) {
+		ends = append(ends, len(text))
+	}
+	return ends
+}
+
+func isSentenceTerminal(r rune) bool {
+	return strings.ContainsRune(".!?…。！？", r)
+}
+
+// truncateRunes returns the longest prefix of s
+// with at most n runes.
+func truncateRunes(s string, n int) string {
+	for i := range s {
+		if n == 0 {
+			return s[:i]
+		}
+		n--
+	}
+	return s
+}
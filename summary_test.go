@@ -0,0 +1,69 @@
+package jsonfeed
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSummarize(t *testing.T) {
+	long := strings.Repeat("word ", 60)
+	cases := []struct {
+		s    Summarizer
+		text string
+		want string
+	}{
+		{Summarizer{}, "", ""},
+		{Summarizer{}, "  Short.\n\n", "Short."},
+		{Summarizer{}, "One. Two! Three? Four.", "One. Two!"},
+		{Summarizer{MaxSentences: 3}, "One. Two! Three? Four.", "One. Two! Three?"},
+		{Summarizer{MaxLen: 10}, "One. Two! Three? Four.", "One. Two!"},
+		{Summarizer{MaxLen: 6}, "One. Two! Three? Four.", "One."},
+		{Summarizer{}, `He said "Go." Then left. Bye.`, `He said "Go." Then left.`},
+		{Summarizer{}, "Pi is 3.14 or so. See example.org. Done.", "Pi is 3.14 or so. See example.org."},
+		{Summarizer{}, "一つ。二つ。三つ。", "一つ。二つ。"},
+		{Summarizer{MaxLen: 12}, "The quick brown fox jumps.", "The quick…"},
+		{Summarizer{MaxLen: 11}, "The quick, brown fox jumps.", "The quick…"},
+		{Summarizer{MaxLen: 10}, "The quick brown fox jumps.", "The quick…"},
+		{Summarizer{MaxLen: 4}, "日本語のテキスト", "日本語…"},
+		{Summarizer{MaxLen: 10, Ellipsis: "..."}, "The quick brown fox jumps.", "The..."},
+		{Summarizer{MaxLen: 9, Policy: EllipsisNever}, "The quick brown fox jumps.", "The quick"},
+		{Summarizer{MaxLen: 2, Ellipsis: "..."}, "The quick brown fox jumps.", ".."},
+		{Summarizer{MaxLen: 5}, "Unbreakable.", "Unbr…"},
+		{Summarizer{MaxLen: 3, Ellipsis: "..."}, "Unbreakable.", "..."},
+		{Summarizer{Policy: EllipsisIfShortened}, "One. Two. Three.", "One. Two. …"},
+		{Summarizer{Policy: EllipsisIfShortened}, "One. Two.", "One. Two."},
+		{Summarizer{MaxLen: 10, Policy: EllipsisIfShortened}, "One. Two. Three.", "One. …"},
+		{Summarizer{}, long, strings.TrimSpace(strings.Repeat("word ", 40)) + "…"},
+	}
+
+	for _, test := range cases {
+		got := test.s.Summarize(test.text)
+		if got != test.want {
+			t.Errorf("(%+v).Summarize(%q) = %q, want %q", test.s, test.text, got, test.want)
+		}
+	}
+}
+
+func TestSummarizeFunc(t *testing.T) {
+	got := Summarize("The quick brown fox jumps.", 12)
+	want := "The quick…"
+	if got != want {
+		t.Errorf("Summarize(...) = %q, want %q", got, want)
+	}
+}
+
+func TestSummarizerFill(t *testing.T) {
+	f := &Feed{Items: []Item{
+		{ID: "1", ContentText: "Text. More."},
+		{ID: "2", ContentHTML: `<p>See <a href="https://example.org/">this</a>.</p><p>More.</p>`},
+		{ID: "3", ContentText: "Text.", Summary: "Kept."},
+	}}
+	s := &Summarizer{MaxSentences: 1}
+	s.Fill(f)
+	want := []string{"Text.", "See this.", "Kept."}
+	for i, t1 := range f.Items {
+		if t1.Summary != want[i] {
+			t.Errorf("item %d summary = %q, want %q", i, t1.Summary, want[i])
+		}
+	}
+}
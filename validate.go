@@ -2,70 +2,107 @@ package jsonfeed
 
 import (
 	"errors"
+	"strconv"
 )
 
 // validFeed returns nil if f is a valid JSON Feed Version 1.
-// Otherwise, it returns an error describing at least one
-// way in which f is invalid.
+// Otherwise, it returns a *FieldError
+// for the first problem found by Validate.
 func validFeed(f *Feed) error {
-	for i := range f.Hubs {
-		if err := validHub(&f.Hubs[i]); err != nil {
-			return err
-		}
+	if probs := Validate(f); probs != nil {
+		return probs[0]
 	}
-	for i := range f.Items {
-		if err := validItem(&f.Items[i]); err != nil {
-			return err
-		}
+	return nil
+}
+
+// validItem returns nil if t is a valid item.
+// Otherwise, it returns a *FieldError
+// for the first problem in t,
+// located under path.
+func validItem(t *Item, path string) error {
+	var p problems
+	p.item(t, path)
+	if p != nil {
+		return p[0]
+	}
+	return nil
+}
+
+// Validate returns the problems that make f invalid,
+// each located by its path in the JSON encoding of f,
+// or nil if f is valid.
+// Unlike json.Unmarshal, which stops at the first problem,
+// Validate reports problems in every hub, item, attachment,
+// and author, for tools that show them to people.
+func Validate(f *Feed) []*FieldError {
+	var p problems
+	if f.Version == "" {
+		p.add("version", errors.New("no version"))
 	}
-	switch {
-	case f.Version == "":
-		return errors.New("jsonfeed: no version")
-	case f.Title == "":
-		return errors.New("jsonfeed: no title")
+	if f.Title == "" {
+		p.add("title", errors.New("no title"))
+	}
+	p.add("author", validAuthor(f.Author))
+	for i := range f.Hubs {
+		p.add("hubs["+strconv.Itoa(i)+"]", validHub(&f.Hubs[i]))
 	}
 	ids := make(map[string]bool)
-	for _, t := range f.Items {
-		if ids[t.ID] {
-			return errors.New("jsonfeed: duplicate id " + t.ID)
+	for i := range f.Items {
+		t := &f.Items[i]
+		path := "items[" + strconv.Itoa(i) + "]"
+		if t.ID != "" && ids[t.ID] {
+			p.add(path+".id", errors.New("duplicate id "+t.ID))
 		}
 		ids[t.ID] = true
+		p.item(t, path)
 	}
-	return validAuthor(f.Author)
+	return p
 }
 
-func validHub(h *Hub) error {
-	switch "" {
-	case h.Type:
-		return errors.New("jsonfeed: no type in hub")
-	case h.URL:
-		return errors.New("jsonfeed: no url in hub")
+// problems collects the problems found by Validate.
+type problems []*FieldError
+
+// add records err, if it is not nil, at path.
+func (p *problems) add(path string, err error) {
+	if err != nil {
+		*p = append(*p, &FieldError{Path: path, Err: err})
 	}
-	return nil
 }
 
-func validItem(t *Item) error {
-	switch "" {
-	case t.ID:
-		return errors.New("jsonfeed: no id in item")
+// item records the problems in t, located under path.
+func (p *problems) item(t *Item, path string) {
+	if t.ID == "" {
+		p.add(path+".id", errors.New("no id in item"))
 	}
 	if t.ContentHTML == "" && t.ContentText == "" {
-		return errors.New("jsonfeed: no content_html or content_text in item " + t.ID)
-	}
-	for i := range t.Attachments {
-		if err := validAttachment(&t.Attachments[i]); err != nil {
-			return err
+		msg := "no content_html or content_text in item"
+		if t.ID != "" {
+			msg += " " + t.ID
 		}
+		p.add(path, errors.New(msg))
+	}
+	for j := range t.Attachments {
+		p.add(path+".attachments["+strconv.Itoa(j)+"]", validAttachment(&t.Attachments[j]))
 	}
-	return validAuthor(t.Author)
+	p.add(path+".author", validAuthor(t.Author))
+}
+
+func validHub(h *Hub) error {
+	switch "" {
+	case h.Type:
+		return errors.New("no type in hub")
+	case h.URL:
+		return errors.New("no url in hub")
+	}
+	return nil
 }
 
 func validAttachment(a *Attachment) error {
 	switch "" {
 	case a.URL:
-		return errors.New("jsonfeed: no url in attachment")
+		return errors.New("no url in attachment")
 	case a.MIMEType:
-		return errors.New("jsonfeed: no mime_type in attachment")
+		return errors.New("no mime_type in attachment")
 	}
 	return nil
 }
@@ -75,7 +112,45 @@ func validAuthor(a *Author) error {
 		return nil
 	}
 	if a.Name == "" && a.URL == "" && a.Avatar == "" {
-		return errors.New("jsonfeed: author must provide name or url or avatar")
+		return errors.New("author must provide name or url or avatar")
+	}
+	return nil
+}
+
+// Lint returns problems in f that do not make it invalid,
+// but that are likely mistakes,
+// such as an expired feed that lists hubs,
+// though it will never publish anything to them,
+// or alternate attachments with the same MIME type,
+// which the spec says should differ.
+// It returns nil if it finds none.
+func Lint(f *Feed) []*FieldError {
+	var probs []*FieldError
+	for _, rule := range lintRules {
+		probs = append(probs, rule(f)...)
+	}
+	return probs
+}
+
+// lintRules lists the checks made by Lint.
+var lintRules = []func(f *Feed) []*FieldError{
+	lintExpiredHubs,
+	lintAlternates,
+}
+
+func lintExpiredHubs(f *Feed) []*FieldError {
+	if f.Expired && len(f.Hubs) > 0 {
+		return []*FieldError{{Path: "hubs", Err: errors.New("expired feed lists hubs")}}
 	}
 	return nil
 }
+
+func lintAlternates(f *Feed) []*FieldError {
+	var probs []*FieldError
+	for i := range f.Items {
+		if err := sameTypeAlternates(&f.Items[i]); err != nil {
+			probs = append(probs, &FieldError{Path: "items[" + strconv.Itoa(i) + "].attachments", Err: err})
+		}
+	}
+	return probs
+}
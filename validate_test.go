@@ -1,6 +1,9 @@
 package jsonfeed
 
-import "testing"
+import (
+	"reflect"
+	"testing"
+)
 
 func TestValidFeed(t *testing.T) {
 	// a valid feed using all features that have validation rules
@@ -16,6 +19,9 @@ func TestValidFeed(t *testing.T) {
 				ContentText: "text",
 				Attachments: []Attachment{
 					{URL: "url", MIMEType: "mimetype"},
+					// alternates of the same type, at different bit rates
+					{URL: "url1", MIMEType: "audio/mpeg", Title: "t", SizeInBytes: 1},
+					{URL: "url2", MIMEType: "audio/mpeg", Title: "t", SizeInBytes: 2},
 				},
 			},
 		},
@@ -24,6 +30,9 @@ func TestValidFeed(t *testing.T) {
 	if err != nil {
 		t.Errorf("validFeed(%#v) = %v, want nil", f, err)
 	}
+	if probs := Validate(f); probs != nil {
+		t.Errorf("Validate(%#v) = %v, want nil", f, probs)
+	}
 }
 
 func TestInvalidFeed(t *testing.T) {
@@ -55,6 +64,9 @@ func TestInvalidFeed(t *testing.T) {
 		if err == nil {
 			t.Errorf("validFeed(%v) = nil, want error", test)
 		}
+		if probs := Validate(test); len(probs) == 0 {
+			t.Errorf("Validate(%v) = nil, want problems", test)
+		}
 	}
 }
 
@@ -70,7 +82,7 @@ func TestInvalidItem(t *testing.T) {
 	}
 
 	for _, test := range cases {
-		err := validItem(test)
+		err := validItem(test, "items[0]")
 		if err == nil {
 			t.Errorf("validItem(%v) = nil, want error", test)
 		}
@@ -112,3 +124,72 @@ func TestInvalidAuthor(t *testing.T) {
 		t.Errorf("validAuthor(%v) = nil, want error", a)
 	}
 }
+
+func TestValidate(t *testing.T) {
+	f := &Feed{
+		Author: &Author{},
+		Hubs:   []Hub{{Type: "type", URL: "url"}, {}},
+		Items: []Item{
+			{ID: "a", ContentText: "a"},
+			{ID: "a", Author: &Author{}},
+			{ContentText: "c", Attachments: []Attachment{
+				{URL: "url", MIMEType: "audio/mpeg", Title: "t"},
+				{URL: "url", MIMEType: "audio/mpeg", Title: "t"},
+				{},
+			}},
+			{},
+		},
+	}
+	var got []string
+	for _, p := range Validate(f) {
+		got = append(got, p.Error())
+	}
+	want := []string{
+		"jsonfeed: version: no version",
+		"jsonfeed: title: no title",
+		"jsonfeed: author: author must provide name or url or avatar",
+		"jsonfeed: hubs[1]: no type in hub",
+		"jsonfeed: items[1].id: duplicate id a",
+		"jsonfeed: items[1]: no content_html or content_text in item a",
+		"jsonfeed: items[1].author: author must provide name or url or avatar",
+		"jsonfeed: items[2].id: no id in item",
+		"jsonfeed: items[2].attachments[2]: no url in attachment",
+		"jsonfeed: items[3].id: no id in item",
+		"jsonfeed: items[3]: no content_html or content_text in item",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Validate() = %q, want %q", got, want)
+	}
+}
+
+func TestLint(t *testing.T) {
+	hubs := []Hub{{Type: "WebSub", URL: "https://example.org/hub"}}
+	cases := []struct {
+		f    *Feed
+		want []string
+	}{
+		{&Feed{}, nil},
+		{&Feed{Hubs: hubs}, nil},
+		{&Feed{Expired: true}, nil},
+		{&Feed{Expired: true, Hubs: hubs}, []string{"hubs"}},
+		{&Feed{Items: []Item{
+			{ID: "a", Attachments: []Attachment{
+				{URL: "a", MIMEType: "audio/mpeg", Title: "t"},
+				{URL: "b", MIMEType: "audio/mp4", Title: "t"},
+			}},
+			{ID: "b", Attachments: []Attachment{
+				{URL: "a", MIMEType: "audio/mpeg", Title: "t"},
+				{URL: "b", MIMEType: "Audio/MPEG", Title: "t"}, // same type as alternate
+			}},
+		}}, []string{"items[1].attachments"}},
+	}
+	for _, test := range cases {
+		var got []string
+		for _, p := range Lint(test.f) {
+			got = append(got, p.Path)
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("Lint(%+v) = %q, want %q", test.f, got, test.want)
+		}
+	}
+}